@@ -2,37 +2,205 @@ package app
 
 import (
 	"context"
+	"fmt"
+	"radicle-github-actions-adapter/app/broker"
+	"radicle-github-actions-adapter/app/githubops"
+	"strings"
 	"time"
 )
 
 type ContextKey string
 
 const (
-	EventUUIDKey             ContextKey    = "event-uuid"
-	RepoClonePathKey         ContextKey    = "repo-path"
-	BrokerResponseFinished   string        = "finished"
-	BrokerResponseTriggered  string        = "triggered"
-	BrokerResponseInProgress string        = "in progress"
-	BrokerResultSuccess      string        = "success"
-	BrokerResultFailure      string        = "failure"
-	WorkflowCheckInterval    time.Duration = 10 * time.Second
+	EventUUIDKey               ContextKey    = "event-uuid"
+	RepoClonePathKey           ContextKey    = "repo-path"
+	BrokerResponseFinished     string        = "finished"
+	BrokerResponseTriggered    string        = "triggered"
+	BrokerResponseInProgress   string        = "in progress"
+	BrokerResponseProgress     string        = "progress"
+	BrokerResultSuccess        string        = "success"
+	BrokerResultFailure        string        = "failure"
+	WorkflowResultStillRunning string        = "still running"
+	CommitResultNoWorkflows    string        = "no_workflows"
+	WorkflowCheckInterval      time.Duration = 10 * time.Second
+	JobTimeoutMargin           time.Duration = 5 * time.Minute
 )
 
 func (ck ContextKey) String() string {
 	return string(ck)
 }
 
+// EventPolicy defines how the adapter handles a kind of broker event.
+type EventPolicy string
+
+const (
+	// EventPolicyCheck waits for the GitHub workflows of the commit.
+	EventPolicyCheck EventPolicy = "check"
+	// EventPolicySkip replies with a successful result without checking GitHub.
+	EventPolicySkip EventPolicy = "skip"
+	// EventPolicyCleanup redacts the adapter's previous comments on the patch and replies like EventPolicySkip.
+	EventPolicyCleanup EventPolicy = "cleanup"
+)
+
+// DefaultEventPolicies checks pushes, tags and new patch revisions. Event kinds not listed here are checked.
+var DefaultEventPolicies = map[broker.EventKind]EventPolicy{
+	broker.EventKindPush:          EventPolicyCheck,
+	broker.EventKindTag:           EventPolicyCheck,
+	broker.EventKindBranchDeleted: EventPolicySkip,
+	broker.EventKindPatchCreated:  EventPolicyCheck,
+	broker.EventKindPatchUpdated:  EventPolicyCheck,
+	broker.EventKindPatchMerged:   EventPolicySkip,
+	broker.EventKindPatchArchived: EventPolicySkip,
+}
+
+// ParseEventPolicies parses a comma separated list of event=policy pairs,
+// e.g. "patch.merged=cleanup,tag=skip", on top of DefaultEventPolicies.
+func ParseEventPolicies(value string) (map[broker.EventKind]EventPolicy, error) {
+	policies := make(map[broker.EventKind]EventPolicy, len(DefaultEventPolicies))
+	for kind, policy := range DefaultEventPolicies {
+		policies[kind] = policy
+	}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+		kind, policy, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid event policy %q, expected event=policy", pair)
+		}
+		switch EventPolicy(strings.TrimSpace(policy)) {
+		case EventPolicyCheck, EventPolicySkip, EventPolicyCleanup:
+			policies[broker.EventKind(strings.TrimSpace(kind))] = EventPolicy(strings.TrimSpace(policy))
+		default:
+			return nil, fmt.Errorf("invalid policy %q for event %q, expected check, skip or cleanup", policy, kind)
+		}
+	}
+	return policies, nil
+}
+
+// ParseTrackingIssues parses a comma separated list of branch=issue pairs, e.g. "main=<ISSUE-ID>", naming the
+// Radicle issue on which the results of the pushes to each branch are reported.
+func ParseTrackingIssues(value string) (map[string]string, error) {
+	issues := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+		branch, issueID, found := strings.Cut(pair, "=")
+		branch, issueID = strings.TrimSpace(branch), strings.TrimSpace(issueID)
+		if !found || len(branch) == 0 || len(issueID) == 0 {
+			return nil, fmt.Errorf("invalid tracking issue %q, expected branch=issue", pair)
+		}
+		issues[branch] = issueID
+	}
+	return issues, nil
+}
+
+// PatchLabels are the labels applied to a patch for each state of its CI check. An empty label is not applied.
+type PatchLabels struct {
+	Pending string
+	Passing string
+	Failing string
+}
+
+// All returns the non empty labels.
+func (pl PatchLabels) All() []string {
+	var labels []string
+	for _, label := range []string{pl.Pending, pl.Passing, pl.Failing} {
+		if len(label) > 0 {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// ParsePatchLabels parses a comma separated list of state=label pairs, e.g. "pending=ci:pending,failing=ci:failing",
+// where state is one of pending, passing and failing.
+func ParsePatchLabels(value string) (PatchLabels, error) {
+	labels := PatchLabels{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+		state, label, found := strings.Cut(pair, "=")
+		if !found || len(strings.TrimSpace(label)) == 0 {
+			return PatchLabels{}, fmt.Errorf("invalid patch label %q, expected state=label", pair)
+		}
+		switch strings.TrimSpace(state) {
+		case "pending":
+			labels.Pending = strings.TrimSpace(label)
+		case "passing":
+			labels.Passing = strings.TrimSpace(label)
+		case "failing":
+			labels.Failing = strings.TrimSpace(label)
+		default:
+			return PatchLabels{}, fmt.Errorf("invalid state %q for label %q, expected pending, passing or failing",
+				state, label)
+		}
+	}
+	return labels, nil
+}
+
 type GitHubActionsSettings struct {
-	GitHubUsername string `yaml:"github_username"`
-	GitHubRepo     string `yaml:"github_repo"`
+	GitHubUsername string   `yaml:"github_username"`
+	GitHubRepo     string   `yaml:"github_repo"`
+	Events         []string `yaml:"events"`
+	HeadBranch     string   `yaml:"head_branch"`
+	// TestReportArtifacts is the name pattern, as of path.Match, of the artifacts with JUnit XML test reports to be
+	// summarized. Test reports are not downloaded when empty.
+	TestReportArtifacts string `yaml:"test_report_artifacts"`
+	// CoverageArtifacts is the name pattern, as of path.Match, of the artifacts with Cobertura, LCOV or Go cover
+	// profile reports to compute the coverage from. Coverage is not computed when empty.
+	CoverageArtifacts string `yaml:"coverage_artifacts"`
+	// CoverageThreshold is the minimal coverage in percent, a lower one fails the result. 0 does not fail it.
+	CoverageThreshold float64 `yaml:"coverage_threshold"`
+	// CommentTemplate is the text/template of the comments with the workflows' results, empty for the default one.
+	CommentTemplate string `yaml:"-"`
+}
+
+// WorkflowRunsFilter returns the filter of the workflow runs to be checked according to the settings.
+func (s GitHubActionsSettings) WorkflowRunsFilter() WorkflowRunsFilter {
+	return WorkflowRunsFilter{
+		Events:     s.Events,
+		HeadBranch: s.HeadBranch,
+	}
+}
+
+// WorkflowRunsFilter limits the checked workflow runs of a commit to the ones triggered by any of Events and to
+// HeadBranch. Empty fields do not filter.
+type WorkflowRunsFilter struct {
+	Events     []string
+	HeadBranch string
 }
 
 type WorkflowResult struct {
-	WorkflowID   string
-	WorkflowName string
-	Status       string
-	Result       string
-	Artifacts    []WorkflowArtifact
+	WorkflowID       string
+	WorkflowName     string
+	Commit           string
+	Status           githubops.WorkflowStatus
+	Result           githubops.WorkflowConclusion
+	Event            string
+	HeadBranch       string
+	RunNumber        int
+	RunAttempt       int
+	CreatedAt        time.Time
+	StartedAt        time.Time
+	UpdatedAt        time.Time
+	HTMLURL          string
+	BillableMS       int64
+	Artifacts        []WorkflowArtifact
+	PreviousAttempts []WorkflowAttempt
+}
+
+type WorkflowAttempt struct {
+	RunID      string
+	RunAttempt int
+	Status     githubops.WorkflowStatus
+	Result     githubops.WorkflowConclusion
+	Url        string
 }
 
 type WorkflowArtifact struct {
@@ -42,9 +210,42 @@ type WorkflowArtifact struct {
 	ApiUrl string
 }
 
+type TestSummary struct {
+	Total       int
+	Passed      int
+	Failed      int
+	Skipped     int
+	Flaky       int
+	FailedTests []FailedTest
+}
+
+type FailedTest struct {
+	Name    string
+	Message string
+	Flaky   bool
+}
+
+type Coverage struct {
+	Covered int
+	Total   int
+}
+
+type WorkflowAnnotation struct {
+	Path      string
+	StartLine int
+	EndLine   int
+	Level     string
+	Title     string
+	Message   string
+}
+
 // GitHubActions should be implemented to retrieve the GitHub Actions' outcome
 type GitHubActions interface {
 	GetRepoCommitWorkflowSetup(ctx context.Context, projectID, commitHash string) (*GitHubActionsSettings, error)
-	GetRepoCommitWorkflowsResults(ctx context.Context, githubUsername, githubRepo,
-		githubCommit string) ([]WorkflowResult, error)
+	GetRepoCommitWorkflowsResults(ctx context.Context, githubUsername, githubRepo, githubCommit string,
+		filter WorkflowRunsFilter) ([]WorkflowResult, error)
+	GetWorkflowRunAnnotations(ctx context.Context, githubUsername, githubRepo, runID string) ([]WorkflowAnnotation,
+		error)
+	GetArtifactTestSummary(ctx context.Context, githubUsername, githubRepo, artifactID string) (*TestSummary, error)
+	GetArtifactCoverage(ctx context.Context, githubUsername, githubRepo, artifactID string) (*Coverage, error)
 }
@@ -2,7 +2,10 @@ package broker
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 )
 
 type RequestMessageType string
@@ -12,7 +15,47 @@ const (
 	RequestMessageTypePatch RequestMessageType = "patch"
 )
 
-var SupportedProtocolVersions = map[uint]bool{1: true}
+const (
+	ProtocolVersion1 uint = 1
+	// ProtocolVersion2 adds the workflows' details and the run's info URL to the response messages.
+	ProtocolVersion2 uint = 2
+)
+
+var SupportedProtocolVersions = map[uint]bool{ProtocolVersion1: true, ProtocolVersion2: true}
+
+var ErrProgressNotSupported = errors.New("progress messages are not supported by the broker protocol version")
+
+var (
+	ErrMalformedRequest = errors.New("malformed request message")
+	ErrMissingField     = errors.New("missing field")
+	ErrInvalidField     = errors.New("invalid field")
+	ErrUnknownField     = errors.New("unknown field")
+	ErrUnsupportedField = errors.New("unsupported field value")
+	ErrRevisionNotFound = errors.New("no patch revision with oid")
+)
+
+// RequestError describes what is wrong with a request message. Err is one of ErrMalformedRequest, ErrMissingField,
+// ErrInvalidField, ErrUnknownField, ErrUnsupportedField or ErrRevisionNotFound and Field the JSON path of the field,
+// e.g. patch.revisions.
+type RequestError struct {
+	Field string
+	Value string
+	Err   error
+}
+
+func (e *RequestError) Error() string {
+	switch {
+	case len(e.Field) == 0:
+		return fmt.Sprintf("%s: %s", e.Err, e.Value)
+	case len(e.Value) == 0:
+		return fmt.Sprintf("%s: %s", e.Err, e.Field)
+	}
+	return fmt.Sprintf("%s: %s %q", e.Err, e.Field, e.Value)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
 
 type RequestTypeMessage struct {
 	Request   string             `json:"request"`
@@ -21,15 +64,51 @@ type RequestTypeMessage struct {
 }
 
 type RequestMessage struct {
+	Version    uint                      `json:"version"`
 	Repo       string                    `json:"repo"`
 	Commit     string                    `json:"commit"`
+	RevisionID string                    `json:"revision_id"`
 	PushEvent  *RequestPushEventMessage  `json:"push_event"`
 	PatchEvent *RequestPatchEventMessage `json:"patch_event"`
 }
 
+// EventKind classifies a request message so that each kind of event can be handled differently.
+// Patch events are "patch.<action>", e.g. "patch.merged".
+type EventKind string
+
+const (
+	EventKindPush          EventKind = "push"
+	EventKindTag           EventKind = "tag"
+	EventKindBranchDeleted EventKind = "branch.deleted"
+	EventKindPatchCreated  EventKind = "patch.created"
+	EventKindPatchUpdated  EventKind = "patch.updated"
+	EventKindPatchMerged   EventKind = "patch.merged"
+	EventKindPatchArchived EventKind = "patch.archived"
+)
+
+// ZeroCommit is the after commit of a push deleting a ref.
+const ZeroCommit = "0000000000000000000000000000000000000000"
+
+const tagRefPrefix = "refs/tags/"
+
+// EventKind returns the kind of the request's event.
+func (rm *RequestMessage) EventKind() EventKind {
+	switch {
+	case rm.PatchEvent != nil:
+		return EventKind("patch." + rm.PatchEvent.Action)
+	case rm.PushEvent == nil:
+		return ""
+	case rm.PushEvent.After == ZeroCommit:
+		return EventKindBranchDeleted
+	case strings.HasPrefix(rm.PushEvent.Branch, tagRefPrefix):
+		return EventKindTag
+	}
+	return EventKindPush
+}
+
 func (rm *RequestMessage) String() string {
-	return fmt.Sprintf("RequestMessage{Repo:%+v, Commit:%+v, PushEvent:%+v, PatchEvent:%+v}", rm.Repo, rm.Commit,
-		*rm.PushEvent, *rm.PatchEvent)
+	return fmt.Sprintf("RequestMessage{Repo:%+v, Commit:%+v, RevisionID:%+v, PushEvent:%+v, PatchEvent:%+v}",
+		rm.Repo, rm.Commit, rm.RevisionID, rm.PushEvent, rm.PatchEvent)
 }
 
 type RequestPushEventMessage struct {
@@ -107,26 +186,140 @@ type ResponseMessage struct {
 	Response      string            `json:"response"`
 	RunID         *RunID            `json:"run_id,omitempty"`
 	Result        string            `json:"result,omitempty"`
+	InfoURL       string            `json:"-"`
 	ResultDetails []WorkflowDetails `json:"-"`
+	// CommitResults holds the result of each checked commit when more than one commit is checked.
+	CommitResults map[string]string `json:"-"`
+	TimedOut      bool              `json:"-"`
+	// Skipped is set when the event's policy skips the GitHub workflows check. Protocol version 1 has no such field,
+	// skipped events are only reported there with a successful result.
+	Skipped bool `json:"-"`
+	// Coverage is only reported when the repo configures its coverage artifacts.
+	Coverage *Coverage `json:"-"`
 }
 
 func (rm *ResponseMessage) String() string {
-	return fmt.Sprintf("ResponseMessage{Response:%+v, RunID:%+v, Result:%+v, ResultDetails:%+v}", rm.Response,
-		*rm.RunID, rm.Result, rm.ResultDetails)
+	return fmt.Sprintf("ResponseMessage{Response:%+v, RunID:%+v, Result:%+v, InfoURL:%+v, ResultDetails:%+v, "+
+		"TimedOut:%+v, Skipped:%+v}", rm.Response, *rm.RunID, rm.Result, rm.InfoURL, rm.ResultDetails, rm.TimedOut,
+		rm.Skipped)
+}
+
+// ResponseMessageV2 is the wire format of a ResponseMessage for protocol version 2 and later.
+type ResponseMessageV2 struct {
+	Response      string            `json:"response"`
+	RunID         *RunID            `json:"run_id,omitempty"`
+	Result        string            `json:"result,omitempty"`
+	InfoURL       string            `json:"info_url,omitempty"`
+	TimedOut      bool              `json:"timed_out,omitempty"`
+	Skipped       bool              `json:"skipped,omitempty"`
+	CommitResults map[string]string `json:"commit_results,omitempty"`
+	ResultDetails []WorkflowDetails `json:"result_details,omitempty"`
+	Coverage      *Coverage         `json:"coverage,omitempty"`
+}
+
+// V2 converts the response message to its protocol version 2 wire format.
+func (rm *ResponseMessage) V2() ResponseMessageV2 {
+	return ResponseMessageV2{
+		Response:      rm.Response,
+		RunID:         rm.RunID,
+		Result:        rm.Result,
+		InfoURL:       rm.InfoURL,
+		TimedOut:      rm.TimedOut,
+		Skipped:       rm.Skipped,
+		CommitResults: rm.CommitResults,
+		ResultDetails: rm.ResultDetails,
+		Coverage:      rm.Coverage,
+	}
 }
 
 type WorkflowDetails struct {
-	WorkflowID        string             `json:"workflow_id"`
-	WorkflowName      string             `json:"workflow_name"`
-	WorkflowResult    string             `json:"workflow_result"`
-	WorkflowArtifacts []WorkflowArtifact `json:"workflow_artifacts"`
+	WorkflowID         string             `json:"workflow_id"`
+	WorkflowName       string             `json:"workflow_name"`
+	WorkflowResult     string             `json:"workflow_result"`
+	WorkflowCommit     string             `json:"workflow_commit,omitempty"`
+	WorkflowAttempt    int                `json:"workflow_attempt,omitempty"`
+	WorkflowRunNumber  int                `json:"workflow_run_number,omitempty"`
+	WorkflowEvent      string             `json:"workflow_event,omitempty"`
+	WorkflowHeadBranch string             `json:"workflow_head_branch,omitempty"`
+	WorkflowCreatedAt  *time.Time         `json:"workflow_created_at,omitempty"`
+	WorkflowStartedAt  *time.Time         `json:"workflow_started_at,omitempty"`
+	WorkflowUpdatedAt  *time.Time         `json:"workflow_updated_at,omitempty"`
+	WorkflowURL        string             `json:"workflow_url,omitempty"`
+	WorkflowBillableMS int64              `json:"workflow_billable_ms,omitempty"`
+	WorkflowArtifacts  []WorkflowArtifact `json:"workflow_artifacts,omitempty"`
+	PreviousAttempts   []WorkflowAttempt  `json:"previous_attempts,omitempty"`
+	// WorkflowAnnotations are only reported for failed workflows.
+	WorkflowAnnotations []WorkflowAnnotation `json:"workflow_annotations,omitempty"`
+	// WorkflowTestSummary is only reported when the repo configures its test report artifacts.
+	WorkflowTestSummary *TestSummary `json:"workflow_test_summary,omitempty"`
+}
+
+// Coverage is the coverage of the checked commit in percent. BasePercent and Delta compare it with the latest
+// coverage recorded for BaseBranch, if any, and a coverage below Threshold fails the result.
+type Coverage struct {
+	Covered     int      `json:"covered"`
+	Total       int      `json:"total"`
+	Percent     float64  `json:"percent"`
+	BaseBranch  string   `json:"base_branch,omitempty"`
+	BasePercent *float64 `json:"base_percent,omitempty"`
+	Delta       *float64 `json:"delta,omitempty"`
+	Threshold   float64  `json:"threshold,omitempty"`
+}
+
+type WorkflowAttempt struct {
+	RunID      string `json:"run_id"`
+	RunAttempt int    `json:"run_attempt,omitempty"`
+	Result     string `json:"result"`
+	Url        string `json:"url"`
+}
+
+type TestSummary struct {
+	Total       int          `json:"total"`
+	Passed      int          `json:"passed"`
+	Failed      int          `json:"failed"`
+	Skipped     int          `json:"skipped"`
+	Flaky       int          `json:"flaky,omitempty"`
+	FailedTests []FailedTest `json:"failed_tests,omitempty"`
+	// MoreFailedTests counts the failed and flaky tests not listed in FailedTests.
+	MoreFailedTests int `json:"more_failed_tests,omitempty"`
+}
+
+type FailedTest struct {
+	Name    string `json:"name"`
+	Message string `json:"message,omitempty"`
+	Flaky   bool   `json:"flaky,omitempty"`
+}
+
+type WorkflowAnnotation struct {
+	Path      string `json:"path,omitempty"`
+	StartLine int    `json:"start_line,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+	Level     string `json:"level"`
+	Title     string `json:"title,omitempty"`
+	Message   string `json:"message"`
 }
 
 type WorkflowArtifact struct {
-	Id     string
-	Name   string
-	Url    string
-	ApiUrl string
+	Id     string `json:"id"`
+	Name   string `json:"name"`
+	Url    string `json:"url"`
+	ApiUrl string `json:"api_url"`
+}
+
+// ProgressMessage reports the workflows whose status changed while the adapter waits for their results.
+type ProgressMessage struct {
+	Response  string             `json:"response"`
+	RunID     *RunID             `json:"run_id,omitempty"`
+	Timestamp time.Time          `json:"timestamp"`
+	Workflows []WorkflowProgress `json:"workflows"`
+}
+
+type WorkflowProgress struct {
+	WorkflowID     string `json:"workflow_id"`
+	WorkflowName   string `json:"workflow_name"`
+	Status         string `json:"status"`
+	PreviousStatus string `json:"previous_status,omitempty"`
+	WorkflowURL    string `json:"workflow_url,omitempty"`
 }
 
 type RunID struct {
@@ -137,4 +330,13 @@ type RunID struct {
 type Broker interface {
 	ParseRequestMessage(ctx context.Context) (*RequestMessage, error)
 	ServeResponse(ctx context.Context, responseMessage ResponseMessage) error
+	// ServeProgress returns ErrProgressNotSupported when the negotiated protocol has no progress messages.
+	ServeProgress(ctx context.Context, progressMessage ProgressMessage) error
+}
+
+// ProgressReporter should be implemented to record the progress messages that the broker can not receive.
+// DeleteProgress removes the progress recorded for a run, if any.
+type ProgressReporter interface {
+	ReportProgress(ctx context.Context, progressMessage ProgressMessage) error
+	DeleteProgress(ctx context.Context, runID string) error
 }
@@ -2,22 +2,120 @@ package githubops
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 )
 
+// WorkflowStatus is the status of a GitHub workflow run.
+type WorkflowStatus string
+
+const (
+	WorkflowStatusCompleted  WorkflowStatus = "completed"
+	WorkflowStatusInProgress WorkflowStatus = "in_progress"
+	WorkflowStatusQueued     WorkflowStatus = "queued"
+	WorkflowStatusRequested  WorkflowStatus = "requested"
+	WorkflowStatusWaiting    WorkflowStatus = "waiting"
+	WorkflowStatusPending    WorkflowStatus = "pending"
+)
+
+// WorkflowConclusion is the conclusion of a completed GitHub workflow run.
+type WorkflowConclusion string
+
 const (
-	WorkflowResultSuccess string = "success"
-	WorkflowResultFailure string = "failure"
+	WorkflowResultSuccess        WorkflowConclusion = "success"
+	WorkflowResultFailure        WorkflowConclusion = "failure"
+	WorkflowResultCancelled      WorkflowConclusion = "cancelled"
+	WorkflowResultSkipped        WorkflowConclusion = "skipped"
+	WorkflowResultNeutral        WorkflowConclusion = "neutral"
+	WorkflowResultTimedOut       WorkflowConclusion = "timed_out"
+	WorkflowResultActionRequired WorkflowConclusion = "action_required"
+	WorkflowResultStale          WorkflowConclusion = "stale"
+	WorkflowResultStartupFailure WorkflowConclusion = "startup_failure"
+)
 
-	WorkflowStatusCompleted  string = "completed"
-	WorkflowStatusInProgress string = "in_progress"
+// ConclusionOutcome defines how a workflow conclusion affects the overall result.
+type ConclusionOutcome string
+
+const (
+	ConclusionOutcomePass    ConclusionOutcome = "pass"
+	ConclusionOutcomeFail    ConclusionOutcome = "fail"
+	ConclusionOutcomeNeutral ConclusionOutcome = "neutral"
 )
 
+// DefaultConclusionOutcomes follows GitHub, which treats skipped and neutral conclusions as passing.
+// Conclusions not listed here fail.
+var DefaultConclusionOutcomes = map[WorkflowConclusion]ConclusionOutcome{
+	WorkflowResultSuccess:        ConclusionOutcomePass,
+	WorkflowResultFailure:        ConclusionOutcomeFail,
+	WorkflowResultCancelled:      ConclusionOutcomeFail,
+	WorkflowResultSkipped:        ConclusionOutcomeNeutral,
+	WorkflowResultNeutral:        ConclusionOutcomeNeutral,
+	WorkflowResultTimedOut:       ConclusionOutcomeFail,
+	WorkflowResultActionRequired: ConclusionOutcomeFail,
+	WorkflowResultStale:          ConclusionOutcomeFail,
+	WorkflowResultStartupFailure: ConclusionOutcomeFail,
+}
+
+// ParseConclusionOutcomes parses a comma separated list of conclusion=outcome pairs,
+// e.g. "cancelled=neutral,skipped=pass", on top of DefaultConclusionOutcomes.
+func ParseConclusionOutcomes(value string) (map[WorkflowConclusion]ConclusionOutcome, error) {
+	outcomes := make(map[WorkflowConclusion]ConclusionOutcome, len(DefaultConclusionOutcomes))
+	for conclusion, outcome := range DefaultConclusionOutcomes {
+		outcomes[conclusion] = outcome
+	}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+		conclusion, outcome, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid conclusion outcome %q, expected conclusion=outcome", pair)
+		}
+		switch ConclusionOutcome(strings.TrimSpace(outcome)) {
+		case ConclusionOutcomePass, ConclusionOutcomeFail, ConclusionOutcomeNeutral:
+			outcomes[WorkflowConclusion(strings.TrimSpace(conclusion))] = ConclusionOutcome(strings.TrimSpace(outcome))
+		default:
+			return nil, fmt.Errorf("invalid outcome %q for conclusion %q, expected pass, fail or neutral", outcome,
+				conclusion)
+		}
+	}
+	return outcomes, nil
+}
+
 type WorkflowResult struct {
-	WorkflowID   string
-	WorkflowName string
-	Status       string
-	Result       string
-	Artifacts    []WorkflowArtifact
+	WorkflowID       string
+	WorkflowName     string
+	Status           WorkflowStatus
+	Result           WorkflowConclusion
+	Event            string
+	HeadBranch       string
+	RunNumber        int
+	RunAttempt       int
+	CreatedAt        time.Time
+	StartedAt        time.Time
+	UpdatedAt        time.Time
+	HTMLURL          string
+	BillableMS       int64
+	Artifacts        []WorkflowArtifact
+	PreviousAttempts []WorkflowAttempt
+}
+
+// WorkflowRunsFilter limits the workflow runs to the ones triggered by any of Events and to HeadBranch.
+// Empty fields do not filter.
+type WorkflowRunsFilter struct {
+	Events     []string
+	HeadBranch string
+}
+
+// WorkflowAttempt is an earlier attempt of a workflow run, or an older run of the same workflow and commit.
+type WorkflowAttempt struct {
+	RunID      string
+	RunAttempt int
+	Status     WorkflowStatus
+	Result     WorkflowConclusion
+	Url        string
 }
 
 type WorkflowArtifact struct {
@@ -27,7 +125,45 @@ type WorkflowArtifact struct {
 	ApiUrl string
 }
 
+// WorkflowAnnotation is an annotation of a check run of a workflow, e.g. a linter warning, on the lines StartLine to
+// EndLine of the file at Path.
+type WorkflowAnnotation struct {
+	Path      string
+	StartLine int
+	EndLine   int
+	Level     string
+	Title     string
+	Message   string
+}
+
+// TestSummary summarizes the JUnit test reports of a workflow run. Flaky tests are counted as passed, and listed with
+// the failed ones in FailedTests.
+type TestSummary struct {
+	Total       int
+	Passed      int
+	Failed      int
+	Skipped     int
+	Flaky       int
+	FailedTests []FailedTest
+}
+
+type FailedTest struct {
+	Name    string
+	Message string
+	Flaky   bool
+}
+
+// Coverage counts the covered and total lines, or statements, of the coverage reports of a workflow run.
+type Coverage struct {
+	Covered int
+	Total   int
+}
+
 type GitHubOps interface {
 	CheckRepoCommit(ctx context.Context, user, repo, commit string) error
-	GetRepoCommitWorkflows(ctx context.Context, user, repo, commit string) ([]WorkflowResult, error)
+	GetRepoCommitWorkflows(ctx context.Context, user, repo, commit string,
+		filter WorkflowRunsFilter) ([]WorkflowResult, error)
+	GetWorkflowRunAnnotations(ctx context.Context, user, repo, runID string) ([]WorkflowAnnotation, error)
+	GetArtifactTestSummary(ctx context.Context, user, repo, artifactID string) (*TestSummary, error)
+	GetArtifactCoverage(ctx context.Context, user, repo, artifactID string) (*Coverage, error)
 }
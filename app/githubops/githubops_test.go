@@ -0,0 +1,50 @@
+package githubops
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseConclusionOutcomes(t *testing.T) {
+	cancelledNeutral := maps.Clone(DefaultConclusionOutcomes)
+	cancelledNeutral[WorkflowResultCancelled] = ConclusionOutcomeNeutral
+	cancelledNeutral[WorkflowResultSkipped] = ConclusionOutcomeFail
+	tests := []struct {
+		name    string
+		value   string
+		want    map[WorkflowConclusion]ConclusionOutcome
+		wantErr bool
+	}{
+		{
+			name:  "ParseConclusionOutcomes returns the defaults without overrides",
+			value: "",
+			want:  DefaultConclusionOutcomes,
+		},
+		{
+			name:  "ParseConclusionOutcomes overrides the defaults",
+			value: "cancelled=neutral, skipped=fail",
+			want:  cancelledNeutral,
+		},
+		{
+			name:    "ParseConclusionOutcomes fails with an invalid outcome",
+			value:   "cancelled=ignore",
+			wantErr: true,
+		},
+		{
+			name:    "ParseConclusionOutcomes fails with a missing outcome",
+			value:   "cancelled",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseConclusionOutcomes(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseConclusionOutcomes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("ParseConclusionOutcomes() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
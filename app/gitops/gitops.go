@@ -1,5 +1,7 @@
 package gitops
 
+import "context"
+
 type GitOps interface {
-	CloneRepoCommit(url, commitHash, repoPath string) error
+	CloneRepoCommit(ctx context.Context, url, commitHash, repoPath string) error
 }
@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	JobPhaseTriggered string = "triggered"
+	JobPhaseWaiting   string = "waiting"
+	JobPhaseFinished  string = "finished"
+	JobPhaseAborted   string = "aborted"
+	// JobResultSkipped is the result of the jobs whose event policy skips the GitHub workflows check.
+	JobResultSkipped string = "skipped"
+)
+
+var ErrJobNotFound = errors.New("job not found")
+
+// Job holds the persisted state of a single broker request handled by the adapter.
+// FailureIssueID is the issue reporting the failures of the default branch. Its state is read from Radicle.
+// Coverage is the coverage in percent computed for the commit, if any.
+// Workflows are the checked workflows with their latest status, updated while the job waits for them.
+type Job struct {
+	ID             string        `json:"id"`
+	Repo           string        `json:"repo"`
+	Commit         string        `json:"commit"`
+	Branch         string        `json:"branch,omitempty"`
+	PatchID        string        `json:"patch_id,omitempty"`
+	RevisionID     string        `json:"revision_id,omitempty"`
+	IssueID        string        `json:"issue_id,omitempty"`
+	CommentID      string        `json:"comment_id,omitempty"`
+	GitHubUsername string        `json:"github_username,omitempty"`
+	GitHubRepo     string        `json:"github_repo,omitempty"`
+	Events         []string      `json:"events,omitempty"`
+	HeadBranch     string        `json:"head_branch,omitempty"`
+	FailureIssueID string        `json:"failure_issue_id,omitempty"`
+	Phase          string        `json:"phase"`
+	Result         string        `json:"result,omitempty"`
+	Coverage       *float64      `json:"coverage,omitempty"`
+	Workflows      []JobWorkflow `json:"workflows,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// JobWorkflow is a workflow run checked by a job. Status is the run's conclusion once completed.
+type JobWorkflow struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Commit string `json:"commit,omitempty"`
+	Status string `json:"status"`
+	URL    string `json:"url,omitempty"`
+}
+
+// Store should be implemented to persist the adapter's jobs across processes.
+type Store interface {
+	Save(ctx context.Context, job Job) error
+	Get(ctx context.Context, id string) (*Job, error)
+	List(ctx context.Context) ([]Job, error)
+	// Delete removes the stored job. Deleting an unknown job is not an error.
+	Delete(ctx context.Context, id string) error
+}
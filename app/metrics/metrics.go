@@ -0,0 +1,18 @@
+package metrics
+
+import "time"
+
+// Metrics should be implemented to record the adapter's metrics.
+type Metrics interface {
+	// ObserveJob records a finished broker job of an event kind, e.g. push or patch.updated, with its result.
+	ObserveJob(event, result string, duration time.Duration)
+	// ObserveTimeToFirstRun records the time from the trigger of a job until its first workflow run was found.
+	ObserveTimeToFirstRun(duration time.Duration)
+	// ObserveGitHubAPICall records a GitHub API call to an endpoint, e.g. /repos/:owner/:repo/actions/runs, with its
+	// HTTP status code, 0 when no response was received.
+	ObserveGitHubAPICall(endpoint string, statusCode int)
+	SetGitHubRateLimitRemaining(remaining int)
+	// ObserveRadicleAction records an action sent to radicle-httpd, e.g. patch.revision.comment, and whether it failed.
+	ObserveRadicleAction(action string, err error)
+	ObserveClone(duration time.Duration)
+}
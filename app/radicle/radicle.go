@@ -4,16 +4,104 @@ import "context"
 
 const CreatePatchCommentType = "revision.comment"
 const EditPatchCommentType = "revision.comment.edit"
+const RedactPatchCommentType = "revision.comment.redact"
+const LabelPatchType = "label"
+const CodeRangeLinesType = "lines"
+const CreateIssueCommentType = "comment"
+const EditIssueCommentType = "comment.edit"
+const IssueLifecycleType = "lifecycle"
+const IssueStatusOpen = "open"
+const IssueStatusClosed = "closed"
+const IssueReasonSolved = "solved"
 
 type CreatePatchComment struct {
-	Type     string   `json:"type"`
-	Body     string   `json:"body"`
-	Revision string   `json:"revision"`
-	Comment  *string  `json:"comment,omitempty"`
-	Embeds   []string `json:"embeds"`
+	Type     string        `json:"type"`
+	Body     string        `json:"body"`
+	Revision string        `json:"revision"`
+	Comment  *string       `json:"comment,omitempty"`
+	Location *CodeLocation `json:"location,omitempty"`
+	Embeds   []string      `json:"embeds"`
+}
+
+// CodeLocation anchors a patch revision comment to the lines of a file in the New version of the commit.
+type CodeLocation struct {
+	Commit string     `json:"commit"`
+	Path   string     `json:"path"`
+	Old    *CodeRange `json:"old"`
+	New    *CodeRange `json:"new"`
+}
+
+type CodeRange struct {
+	Type  string    `json:"type"`
+	Range LineRange `json:"range"`
+}
+
+// LineRange is the range of lines from Start up to, but excluding, End.
+type LineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+type RedactPatchComment struct {
+	Type     string `json:"type"`
+	Revision string `json:"revision"`
+	Comment  string `json:"comment"`
+}
+
+type LabelPatch struct {
+	Type   string   `json:"type"`
+	Labels []string `json:"labels"`
+}
+
+// CreateIssueComment replies to the issue's root comment, whose ID is the issue's ID.
+type CreateIssueComment struct {
+	Type    string   `json:"type"`
+	Body    string   `json:"body"`
+	ReplyTo string   `json:"replyTo"`
+	Embeds  []string `json:"embeds"`
+}
+
+type EditIssueComment struct {
+	Type   string   `json:"type"`
+	ID     string   `json:"id"`
+	Body   string   `json:"body"`
+	Embeds []string `json:"embeds"`
+}
+
+type CreateIssue struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Labels      []string `json:"labels"`
+	Assignees   []string `json:"assignees"`
+	Embeds      []string `json:"embeds"`
+}
+
+type IssueState struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type IssueLifecycle struct {
+	Type  string     `json:"type"`
+	State IssueState `json:"state"`
 }
 
 // Patch should be implemented to support actions on Redicle patch
 type Patch interface {
-	Comment(ctx context.Context, repoID, patchID, revisionID, message string, append bool) error
+	Comment(ctx context.Context, repoID, patchID, revisionID, message string, append bool) (string, error)
+	EditComment(ctx context.Context, repoID, patchID, revisionID, commentID, message string) error
+	RedactComment(ctx context.Context, repoID, patchID, revisionID, commentID string) error
+	SetLabels(ctx context.Context, repoID, patchID string, labels []string) error
+	CommentCode(ctx context.Context, repoID, patchID, revisionID string, location CodeLocation,
+		message string) (string, error)
+}
+
+// Issue should be implemented to report on a Radicle issue, e.g. the results of push events on a tracking issue
+type Issue interface {
+	CommentIssue(ctx context.Context, repoID, issueID, message string, append bool) (string, error)
+	EditIssueComment(ctx context.Context, repoID, issueID, commentID, message string) error
+	AddIssueComment(ctx context.Context, repoID, issueID, message string) (string, error)
+	CreateIssue(ctx context.Context, repoID, title, description string) (string, error)
+	SetIssueOpen(ctx context.Context, repoID, issueID string, open bool) error
+	IssueOpen(ctx context.Context, repoID, issueID string) (bool, error)
 }
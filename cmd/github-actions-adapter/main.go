@@ -6,12 +6,19 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
 	"radicle-github-actions-adapter/app"
 	"radicle-github-actions-adapter/app/broker"
+	"radicle-github-actions-adapter/app/githubops"
 	"radicle-github-actions-adapter/cmd/github-actions-adapter/serve"
+	"radicle-github-actions-adapter/cmd/github-actions-adapter/status"
 	"radicle-github-actions-adapter/internal/git"
 	"radicle-github-actions-adapter/internal/github"
+	"radicle-github-actions-adapter/internal/jobstore"
+	"radicle-github-actions-adapter/internal/metrics"
+	"radicle-github-actions-adapter/internal/progresslog"
 	"radicle-github-actions-adapter/internal/radicle"
 	"radicle-github-actions-adapter/internal/radiclegithubactions"
 	"radicle-github-actions-adapter/internal/readerwriterbroker"
@@ -19,10 +26,17 @@ import (
 	"radicle-github-actions-adapter/pkg/gohome"
 	"radicle-github-actions-adapter/pkg/version"
 	"strings"
+	"syscall"
+	"time"
 )
 
 var eventUUID = uuid.New().String()
 
+const (
+	reconcileCommand = "reconcile"
+	statusCommand    = "status"
+)
+
 func main() {
 	envLogLevel := env.GetString("LOG_LEVEL", "info")
 	logLevel := flag.String("loglevel", envLogLevel, "Log level: debug, info, warn, error")
@@ -66,6 +80,24 @@ func main() {
 	logger := slog.New(logHandler)
 	slog.SetDefault(logger)
 
+	if flag.Arg(0) == reconcileCommand {
+		err = reconcile(logger)
+		if err != nil {
+			logger.Error("could not reconcile jobs", "error", err.Error())
+			os.Exit(1)
+		}
+		logger.Info("radicle-github-actions-adapter reconciled jobs successfully")
+		return
+	}
+	if flag.Arg(0) == statusCommand {
+		err = runStatus(logger)
+		if err != nil {
+			logger.Error("could not run status server", "error", err.Error())
+			os.Exit(1)
+		}
+		logger.Info("radicle-github-actions-adapter status server stopped")
+		return
+	}
 	err = run(logger)
 	if err != nil {
 		logger.Error("could not run radicle-github-actions-adapter", "error", err.Error())
@@ -83,7 +115,7 @@ func (h HandlerWithTraceID) Handle(ctx context.Context, r slog.Record) error {
 	return h.Handler.Handle(ctx, r)
 }
 
-func run(logger *slog.Logger) error {
+func loadConfig() serve.AppConfig {
 	var cfg serve.AppConfig
 	cfg.RadicleHome = gohome.Expand(env.GetString("RAD_HOME", "~/.radicle"))
 	cfg.RadicleHttpdURL = env.GetString("RAD_HTTPD_URL", "http://127.0.0.1:8080")
@@ -97,44 +129,196 @@ func run(logger *slog.Logger) error {
 	if cfg.WorkflowsPollTimoutSecs == 0 {
 		cfg.WorkflowsPollTimoutSecs = 30 * 60
 	}
+	conclusionOutcomes, err := githubops.ParseConclusionOutcomes(env.GetString("WORKFLOWS_CONCLUSION_OUTCOMES", ""))
+	if err != nil {
+		panic(err)
+	}
+	cfg.ConclusionOutcomes = conclusionOutcomes
+	eventPolicies, err := app.ParseEventPolicies(env.GetString("EVENT_POLICIES", ""))
+	if err != nil {
+		panic(err)
+	}
+	cfg.EventPolicies = eventPolicies
+	trackingIssues, err := app.ParseTrackingIssues(env.GetString("PUSH_TRACKING_ISSUES", ""))
+	if err != nil {
+		panic(err)
+	}
+	cfg.PushTrackingIssues = trackingIssues
+	patchLabels, err := app.ParsePatchLabels(env.GetString("PATCH_LABELS", ""))
+	if err != nil {
+		panic(err)
+	}
+	cfg.PatchLabels = patchLabels
+	cfg.CommentTemplatesDir = gohome.Expand(env.GetString("COMMENT_TEMPLATES_DIR", ""))
+	cfg.DefaultBranchFailureIssues = env.GetBool("DEFAULT_BRANCH_FAILURE_ISSUES", false)
+	cfg.PushCheckAllCommits = env.GetBool("PUSH_CHECK_ALL_COMMITS", false)
+	cfg.PushMaxCommits = env.GetUint64("PUSH_MAX_COMMITS", 20)
+	if cfg.PushMaxCommits == 0 {
+		cfg.PushMaxCommits = 20
+	}
+	cfg.AnnotationsMaxComments = env.GetUint64("ANNOTATIONS_MAX_COMMENTS", 10)
+	cfg.WorkflowsTimeoutNeutral = env.GetBool("WORKFLOWS_TIMEOUT_NEUTRAL", false)
+	cfg.WorkflowsBillableTime = env.GetBool("WORKFLOWS_BILLABLE_TIME", false)
+	cfg.JobTimeoutSecs = env.GetUint64("JOB_TIMEOUT_SECS", 0)
+	cfg.ShutdownGraceSecs = env.GetUint64("SHUTDOWN_GRACE_SECS", 10)
+	if cfg.ShutdownGraceSecs == 0 {
+		cfg.ShutdownGraceSecs = 10
+	}
+	cfg.JobsStateDir = gohome.Expand(env.GetString("JOBS_STATE_DIR", "~/.radicle-github-actions-adapter/jobs"))
+	cfg.JobsRetentionDays = env.GetUint64("JOBS_RETENTION_DAYS", 30)
+	cfg.StatusPageURL = env.GetString("STATUS_PAGE_URL", "")
+	cfg.StatusListenAddr = env.GetString("STATUS_LISTEN_ADDR", "127.0.0.1:8090")
+	cfg.MetricsTextfile = gohome.Expand(env.GetString("METRICS_TEXTFILE", ""))
+	cfg.BrokerStrictParsing = env.GetBool("BROKER_STRICT_PARSING", false)
+	cfg.ProgressLogDir = gohome.Expand(env.GetString("PROGRESS_LOG_DIR",
+		"~/.radicle-github-actions-adapter/progress"))
+	return cfg
+}
 
+func run(logger *slog.Logger) error {
+	cfg := loadConfig()
 	logger.Debug("starting with configuration", "RadicleHome", cfg.RadicleHome, "RadicleHttpdURL", cfg.RadicleHttpdURL,
 		"RadicleSessionToken length", len(cfg.RadicleSessionToken), "WorkflowsPollTimoutSecs",
-		cfg.WorkflowsPollTimoutSecs, "GitHubPAT length", len(cfg.GitHubPAT))
+		cfg.WorkflowsPollTimoutSecs, "GitHubPAT length", len(cfg.GitHubPAT), "JobTimeoutSecs", cfg.JobTimeoutSecs,
+		"WorkflowsTimeoutNeutral", cfg.WorkflowsTimeoutNeutral, "WorkflowsBillableTime", cfg.WorkflowsBillableTime,
+		"ConclusionOutcomes", cfg.ConclusionOutcomes,
+		"EventPolicies", cfg.EventPolicies, "PushCheckAllCommits", cfg.PushCheckAllCommits, "PushMaxCommits",
+		cfg.PushMaxCommits, "PushTrackingIssues", cfg.PushTrackingIssues,
+		"DefaultBranchFailureIssues", cfg.DefaultBranchFailureIssues, "PatchLabels", cfg.PatchLabels,
+		"CommentTemplatesDir", cfg.CommentTemplatesDir, "AnnotationsMaxComments", cfg.AnnotationsMaxComments,
+		"JobsStateDir", cfg.JobsStateDir, "ProgressLogDir", cfg.ProgressLogDir,
+		"StatusPageURL", cfg.StatusPageURL, "BrokerStrictParsing", cfg.BrokerStrictParsing,
+		"MetricsTextfile", cfg.MetricsTextfile)
+	registry := metrics.NewRegistry()
+	defer writeMetrics(logger, registry, cfg.MetricsTextfile)
 
 	var application serve.App
 	application.Config = cfg
 	application.Logger = logger
 
-	ctx := context.WithValue(context.Background(), app.EventUUIDKey, eventUUID)
+	ctx, stop := signalContext(logger)
+	defer stop()
+	ctx = context.WithValue(ctx, app.EventUUIDKey, eventUUID)
 	ctx = context.WithValue(ctx, app.RepoClonePathKey, eventUUID)
 
 	logger.Info("radicle-github-actions-adapter is starting", "version", version.GetVersion(),
 		"revision", version.GetRevision(), "build_time", version.GetBuildTime())
-	radicleBroker := readerwriterbroker.NewReaderWriterBroker(os.Stdin, os.Stdout, logger)
-	gitOps := git.NewGit(logger)
-	gitHubOps := github.NewGitHub(cfg.GitHubPAT, logger)
+	radicleBroker := readerwriterbroker.NewReaderWriterBroker(os.Stdin, os.Stdout, cfg.BrokerStrictParsing, logger)
+	gitOps := git.NewGit(logger, registry)
+	gitHubOps := github.NewGitHub(cfg.GitHubPAT, cfg.WorkflowsBillableTime, logger, registry)
 	gitHubActions := radiclegithubactions.NewRadicleGitHubActions(cfg.RadicleHome, gitOps, gitHubOps, logger)
-	radiclePatch := radicle.NewRadicle(cfg.RadicleHttpdURL, cfg.RadicleSessionToken, logger)
-	srv := serve.NewGitHubActionsServer(&application, radicleBroker, gitHubActions, radiclePatch)
+	radiclePatch := radicle.NewRadicle(cfg.RadicleHttpdURL, cfg.RadicleSessionToken, logger, registry)
+	jobStore := jobstore.NewJobStore(cfg.JobsStateDir, logger)
+	progressLog := progresslog.NewProgressLog(cfg.ProgressLogDir, logger)
+	srv := serve.NewGitHubActionsServer(&application, radicleBroker, gitHubActions, radiclePatch, radiclePatch,
+		jobStore, progressLog, registry)
 
 	defer func() {
 		if r := recover(); r != nil {
 			err := fmt.Errorf("%+v", r)
-			_ = handleAppError(ctx, logger, err, radicleBroker)
+			_ = handleAppError(ctx, logger, err, radicleBroker, cfg.ShutdownGraceSecs)
 		}
 	}()
 	err := srv.Serve(ctx)
 	if err != nil {
-		return handleAppError(ctx, logger, err, radicleBroker)
+		return handleAppError(ctx, logger, err, radicleBroker, cfg.ShutdownGraceSecs)
 	}
 
 	return nil
 }
 
+// reconcile updates the stored jobs that were left unfinished by a previous adapter process.
+func reconcile(logger *slog.Logger) error {
+	cfg := loadConfig()
+	logger.Debug("reconciling with configuration", "RadicleHttpdURL", cfg.RadicleHttpdURL,
+		"WorkflowsPollTimoutSecs", cfg.WorkflowsPollTimoutSecs, "JobsStateDir", cfg.JobsStateDir,
+		"JobsRetentionDays", cfg.JobsRetentionDays, "MetricsTextfile", cfg.MetricsTextfile)
+	registry := metrics.NewRegistry()
+	defer writeMetrics(logger, registry, cfg.MetricsTextfile)
+
+	var application serve.App
+	application.Config = cfg
+	application.Logger = logger
+
+	ctx, stop := signalContext(logger)
+	defer stop()
+	ctx = context.WithValue(ctx, app.EventUUIDKey, eventUUID)
+	gitHubOps := github.NewGitHub(cfg.GitHubPAT, cfg.WorkflowsBillableTime, logger, registry)
+	gitHubActions := radiclegithubactions.NewRadicleGitHubActions(cfg.RadicleHome, git.NewGit(logger, registry),
+		gitHubOps, logger)
+	radiclePatch := radicle.NewRadicle(cfg.RadicleHttpdURL, cfg.RadicleSessionToken, logger, registry)
+	jobStore := jobstore.NewJobStore(cfg.JobsStateDir, logger)
+	progressLog := progresslog.NewProgressLog(cfg.ProgressLogDir, logger)
+	srv := serve.NewGitHubActionsServer(&application, nil, gitHubActions, radiclePatch, radiclePatch, jobStore,
+		progressLog, registry)
+	return srv.Reconcile(ctx)
+}
+
+// runStatus serves the status pages of the stored jobs until the process is terminated.
+func runStatus(logger *slog.Logger) error {
+	cfg := loadConfig()
+	logger.Debug("serving status with configuration", "StatusListenAddr", cfg.StatusListenAddr, "JobsStateDir",
+		cfg.JobsStateDir, "MetricsTextfile", cfg.MetricsTextfile)
+
+	ctx, stop := signalContext(logger)
+	defer stop()
+	jobStore := jobstore.NewJobStore(cfg.JobsStateDir, logger)
+	server := &http.Server{
+		Addr:              cfg.StatusListenAddr,
+		Handler:           status.NewStatusServer(jobStore, cfg.MetricsTextfile, logger),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+	logger.Info("radicle-github-actions-adapter status server is listening", "address", cfg.StatusListenAddr)
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(cfg.ShutdownGraceSecs))
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+// writeMetrics adds the metrics recorded by the process to the node_exporter textfile, when configured.
+func writeMetrics(logger *slog.Logger, registry *metrics.Registry, textfile string) {
+	if len(textfile) == 0 {
+		return
+	}
+	err := registry.WriteTextfile(textfile)
+	if err != nil {
+		logger.Warn("could not write metrics textfile", "path", textfile, "error", err.Error())
+	}
+}
+
+// signalContext returns a context which is cancelled on SIGTERM or SIGINT.
+// Once cancelled, a second signal terminates the process immediately.
+func signalContext(logger *slog.Logger) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	go func() {
+		select {
+		case sig := <-signals:
+			logger.Warn("received termination signal, shutting down", "signal", sig.String())
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(signals)
+	}()
+	return ctx, cancel
+}
+
+// handleAppError reports a failure to the broker. As ctx may already be cancelled on shutdown, the response is sent
+// through a detached context bounded by graceSecs.
 func handleAppError(ctx context.Context, logger *slog.Logger, err error,
-	radicleBroker *readerwriterbroker.ReaderWriterBroker) error {
+	radicleBroker *readerwriterbroker.ReaderWriterBroker, graceSecs uint64) error {
 	logger.Error("could not serve radicle gitHub actions", "error", err.Error())
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second*time.Duration(graceSecs))
+	defer cancel()
 	resultErrorResponse := broker.ResponseMessage{
 		Response: app.BrokerResponseFinished,
 		Result:   app.BrokerResultFailure,
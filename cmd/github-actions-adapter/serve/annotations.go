@@ -0,0 +1,111 @@
+package serve
+
+import (
+	"context"
+	"radicle-github-actions-adapter/app"
+	"radicle-github-actions-adapter/app/broker"
+	"radicle-github-actions-adapter/app/githubops"
+	"radicle-github-actions-adapter/app/radicle"
+	"strconv"
+)
+
+// runAnnotationsPath is the path of the annotations GitHub adds about the run itself rather than a file, e.g. a
+// failed step's exit code.
+const runAnnotationsPath string = ".github"
+
+// addAnnotations adds the annotations of the failed workflows to the response's details. Failing to fetch them is
+// only logged, as the workflows' results are known already.
+func (gas *GitHubActionsServer) addAnnotations(ctx context.Context, gitHubActionsSettings *app.GitHubActionsSettings,
+	resultResponse *broker.ResponseMessage) {
+	for i, workflowDetails := range resultResponse.ResultDetails {
+		if workflowDetails.WorkflowResult != string(githubops.WorkflowResultFailure) {
+			continue
+		}
+		annotations, err := gas.GitHubActions.GetWorkflowRunAnnotations(ctx, gitHubActionsSettings.GitHubUsername,
+			gitHubActionsSettings.GitHubRepo, workflowDetails.WorkflowID)
+		if err != nil {
+			gas.App.Logger.Warn("could not get workflow annotations", "workflow_id", workflowDetails.WorkflowID,
+				"error", err.Error())
+			continue
+		}
+		for _, annotation := range annotations {
+			resultResponse.ResultDetails[i].WorkflowAnnotations = append(
+				resultResponse.ResultDetails[i].WorkflowAnnotations, broker.WorkflowAnnotation{
+					Path:      annotation.Path,
+					StartLine: annotation.StartLine,
+					EndLine:   annotation.EndLine,
+					Level:     annotation.Level,
+					Title:     annotation.Title,
+					Message:   annotation.Message,
+				})
+		}
+	}
+}
+
+// commentAnnotations comments the annotations of files on the lines of the patch revision they refer to, up to
+// AnnotationsMaxComments. The annotations that are not commented are listed in the result comment instead.
+func (gas *GitHubActionsServer) commentAnnotations(ctx context.Context, brokerRequestMessage *broker.RequestMessage,
+	resultResponse broker.ResponseMessage) {
+	if brokerRequestMessage.PatchEvent == nil || len(brokerRequestMessage.RevisionID) == 0 {
+		return
+	}
+	gas.codeAnnotations = map[broker.WorkflowAnnotation]bool{}
+	for _, workflowDetails := range resultResponse.ResultDetails {
+		for _, annotation := range workflowDetails.WorkflowAnnotations {
+			if !isCodeAnnotation(annotation) || gas.codeAnnotations[annotation] {
+				continue
+			}
+			if uint64(len(gas.codeAnnotations)) >= gas.App.Config.AnnotationsMaxComments {
+				gas.App.Logger.Debug("not commenting all annotations on the patch", "max",
+					gas.App.Config.AnnotationsMaxComments)
+				return
+			}
+			location := radicle.CodeLocation{
+				Commit: brokerRequestMessage.Commit,
+				Path:   annotation.Path,
+				New: &radicle.CodeRange{
+					Type: radicle.CodeRangeLinesType,
+					Range: radicle.LineRange{
+						Start: annotation.StartLine,
+						End:   max(annotation.EndLine, annotation.StartLine) + 1,
+					},
+				},
+			}
+			_, err := gas.Radicle.CommentCode(ctx, brokerRequestMessage.Repo, brokerRequestMessage.PatchEvent.Patch.ID,
+				brokerRequestMessage.RevisionID, location, annotationComment(workflowDetails.WorkflowName, annotation))
+			if err != nil {
+				gas.App.Logger.Warn("could not comment annotation on patch", "path", annotation.Path, "line",
+					annotation.StartLine, "error", err.Error())
+				continue
+			}
+			gas.codeAnnotations[annotation] = true
+		}
+	}
+}
+
+// isCodeAnnotation returns whether the annotation refers to lines of a file of the repo.
+func isCodeAnnotation(annotation broker.WorkflowAnnotation) bool {
+	return len(annotation.Path) > 0 && annotation.Path != runAnnotationsPath && annotation.StartLine > 0
+}
+
+// annotationComment returns the code comment of an annotation of the workflow.
+func annotationComment(workflowName string, annotation broker.WorkflowAnnotation) string {
+	comment := "**" + annotation.Level + "**"
+	if len(annotation.Title) > 0 {
+		comment += " " + annotation.Title
+	}
+	return comment + " (GitHub Actions " + workflowName + ")  \n" + annotation.Message
+}
+
+// annotationLocation returns the file and lines of an annotation, e.g. main.go:3-5, or an empty string if it does
+// not refer to a file.
+func annotationLocation(annotation broker.WorkflowAnnotation) string {
+	if !isCodeAnnotation(annotation) {
+		return ""
+	}
+	location := annotation.Path + ":" + strconv.Itoa(annotation.StartLine)
+	if annotation.EndLine > annotation.StartLine {
+		location += "-" + strconv.Itoa(annotation.EndLine)
+	}
+	return location
+}
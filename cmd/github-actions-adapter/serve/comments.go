@@ -7,76 +7,157 @@ import (
 	"radicle-github-actions-adapter/app"
 	"radicle-github-actions-adapter/app/broker"
 	"radicle-github-actions-adapter/app/githubops"
+	"strings"
+	"time"
 )
 
-// commentResultOnPatch adds a patch-revision comment with the results of the GitHub workflows.
+// canComment reports whether the results of the request are commented on, i.e. for patches and for pushes to a
+// branch with a tracking issue.
+func (gas *GitHubActionsServer) canComment(brokerRequestMessage *broker.RequestMessage) bool {
+	return brokerRequestMessage.PatchEvent != nil || len(gas.trackingIssue(brokerRequestMessage)) > 0
+}
+
+// comment adds a comment with the results of the GitHub workflows on the patch revision under test or on the
+// tracking issue of the pushed branch.
+func (gas *GitHubActionsServer) comment(ctx context.Context, brokerRequestMessage *broker.RequestMessage,
+	commentMessage string, append bool) error {
+	if brokerRequestMessage.PatchEvent != nil {
+		return gas.commentOnPatch(ctx, brokerRequestMessage, commentMessage, append)
+	}
+	return gas.commentOnIssue(ctx, brokerRequestMessage, commentMessage, append)
+}
+
+// trackingIssue returns the ID of the issue configured for the branch of a push event, if any.
+func (gas *GitHubActionsServer) trackingIssue(brokerRequestMessage *broker.RequestMessage) string {
+	if gas.Issues == nil || brokerRequestMessage.PushEvent == nil ||
+		brokerRequestMessage.EventKind() != broker.EventKindPush {
+		return ""
+	}
+	return gas.App.Config.PushTrackingIssues[pushedBranch(brokerRequestMessage)]
+}
+
+// commentOnIssue adds a comment with the results of the GitHub workflows of a push on the branch's tracking issue.
+// The comment starts with the pushed branch and commit, since the issue collects the results of every push.
+func (gas *GitHubActionsServer) commentOnIssue(ctx context.Context,
+	brokerRequestMessage *broker.RequestMessage, commentMessage string, append bool) error {
+	issueID := gas.trackingIssue(brokerRequestMessage)
+	if len(issueID) == 0 {
+		return errors.New("no tracking issue configured for branch " + brokerRequestMessage.PushEvent.Branch)
+	}
+	if !append {
+		commentMessage = pushCommentMessage(brokerRequestMessage.Commit, pushedBranch(brokerRequestMessage),
+			commentMessage)
+	}
+	commentID, err := gas.Issues.CommentIssue(ctx, brokerRequestMessage.Repo, issueID, commentMessage, append)
+	if len(commentID) > 0 && commentID != gas.job.CommentID {
+		gas.job.CommentID = commentID
+		gas.saveJob(ctx)
+	}
+	if err != nil {
+		gas.App.Logger.Warn("could not comment on issue", "content", commentMessage, "issue_id", issueID, "error",
+			err.Error())
+		return err
+	}
+	gas.App.Logger.Debug("successfully added issue comment", "content", commentMessage, "issue_id", issueID)
+	return nil
+}
+
+// pushCommentMessage names the pushed commit and branch in a comment on the branch's tracking issue.
+func pushCommentMessage(commit, branch, commentMessage string) string {
+	return fmt.Sprintf("Push of `%s` to `%s`  \n %s", commit, branch, commentMessage)
+}
+
+// commentOnPatch adds a comment with the results of the GitHub workflows on the patch revision under test.
 func (gas *GitHubActionsServer) commentOnPatch(ctx context.Context,
 	brokerRequestMessage *broker.RequestMessage, commentMessage string, append bool) error {
-	if len(brokerRequestMessage.PatchEvent.Patch.Revisions) == 0 {
-		gas.App.Logger.Warn("could not comment on patch", "error", "no revision found in patch")
-		return errors.New("no revision found in patch")
+	revisionID := brokerRequestMessage.RevisionID
+	if len(revisionID) == 0 {
+		gas.App.Logger.Warn("could not comment on patch", "error", "no revision of the patch matches the commit",
+			"commit", brokerRequestMessage.Commit)
+		return errors.New("no revision of the patch matches commit " + brokerRequestMessage.Commit)
+	}
+	commentID, err := gas.Radicle.Comment(ctx, brokerRequestMessage.Repo, brokerRequestMessage.PatchEvent.Patch.ID,
+		revisionID, commentMessage, append)
+	if len(commentID) > 0 && commentID != gas.job.CommentID {
+		gas.job.CommentID = commentID
+		gas.saveJob(ctx)
 	}
-	revision := brokerRequestMessage.PatchEvent.Patch.Revisions[len(brokerRequestMessage.PatchEvent.Patch.Revisions)-1]
-	err := gas.Radicle.Comment(ctx, brokerRequestMessage.Repo, brokerRequestMessage.PatchEvent.Patch.ID, revision.ID,
-		commentMessage, append)
 	if err != nil {
 		gas.App.Logger.Warn("could not comment on patch", "content", commentMessage, "patch_id",
-			brokerRequestMessage.PatchEvent.Patch.ID, "revision_id", revision.ID, "error", err.Error())
+			brokerRequestMessage.PatchEvent.Patch.ID, "revision_id", revisionID, "error", err.Error())
 		return err
 	}
 	gas.App.Logger.Debug("successfully added patch comment", "content", commentMessage, "patch_id",
-		brokerRequestMessage.PatchEvent.Patch.ID, "revision_id", revision.ID)
+		brokerRequestMessage.PatchEvent.Patch.ID, "revision_id", revisionID)
 	return nil
 
 }
 
 // preparePatchCommentResultMessage prepares a message for adding as patch comment with the workflow results.
+// The message is rendered from the comment template of gitHubActionsSettings, see renderComment.
 func (gas *GitHubActionsServer) preparePatchCommentResultMessage(resultResponse broker.ResponseMessage,
 	gitHubActionsSettings app.GitHubActionsSettings) string {
-	githubWorkflowURL := "https://github.com/%s/%s/actions/runs/%s"
-	actionsStatus := "Result"
-	if resultResponse.Response == app.BrokerResponseInProgress {
-		actionsStatus = "Status"
+	return gas.renderComment(gas.commentData(resultResponse, gitHubActionsSettings), gitHubActionsSettings)
+}
+
+// workflowRunDetails describes which run of the workflow was evaluated, e.g. "run 12, attempt 2, push on main".
+func workflowRunDetails(result broker.WorkflowDetails) string {
+	var details []string
+	if result.WorkflowRunNumber > 0 {
+		details = append(details, fmt.Sprintf("run %d", result.WorkflowRunNumber))
+	}
+	if result.WorkflowAttempt > 1 {
+		details = append(details, fmt.Sprintf("attempt %d", result.WorkflowAttempt))
+	}
+	trigger := result.WorkflowEvent
+	if len(result.WorkflowHeadBranch) > 0 {
+		trigger = strings.TrimSpace(trigger + " on " + result.WorkflowHeadBranch)
 	}
-	commentMessage := "GitHub Actions " + actionsStatus + ": "
-	if len(resultResponse.ResultDetails) == 0 {
-		commentMessage += "No workflows found"
-		return commentMessage
+	if len(trigger) > 0 {
+		details = append(details, trigger)
 	}
+	return strings.Join(details, ", ")
+}
 
-	if resultResponse.Response == app.BrokerResponseInProgress {
-		commentMessage += app.BrokerResponseInProgress
-		commentMessage += " ⏳"
-	} else {
-		commentMessage += resultResponse.Result
-		if resultResponse.Result == app.BrokerResultSuccess {
-			commentMessage += " ✅"
-		} else {
-			commentMessage += " ❌"
-		}
+// workflowResultIcon returns the icon of a workflow's status or conclusion as reported in the workflow details.
+func workflowResultIcon(workflowResult string) string {
+	switch workflowResult {
+	case string(githubops.WorkflowStatusInProgress):
+		return "⏳"
+	case string(githubops.WorkflowStatusQueued), string(githubops.WorkflowStatusRequested),
+		string(githubops.WorkflowStatusPending):
+		return "🕒"
+	case string(githubops.WorkflowStatusWaiting):
+		return "⏸️"
+	case app.WorkflowResultStillRunning:
+		return "⏱️"
+	case string(githubops.WorkflowResultSuccess):
+		return "✅"
+	case string(githubops.WorkflowResultFailure), string(githubops.WorkflowResultStartupFailure):
+		return "❌"
+	case string(githubops.WorkflowResultCancelled):
+		return "🚫"
+	case string(githubops.WorkflowResultSkipped):
+		return "⏭️"
+	case string(githubops.WorkflowResultNeutral):
+		return "➖"
+	case string(githubops.WorkflowResultTimedOut):
+		return "⌛"
+	case string(githubops.WorkflowResultActionRequired):
+		return "✋"
+	case string(githubops.WorkflowResultStale):
+		return "💤"
 	}
-	commentMessage += "  \n Workflows:"
-	for _, result := range resultResponse.ResultDetails {
-		url := fmt.Sprintf(githubWorkflowURL, gitHubActionsSettings.GitHubUsername, gitHubActionsSettings.GitHubRepo,
-			result.WorkflowID)
-		commentMessage += "  \n - "
-		icon := "⚠️️"
-		if result.WorkflowResult == githubops.WorkflowStatusInProgress {
-			icon = "⏳"
-		} else if result.WorkflowResult == githubops.WorkflowResultSuccess {
-			icon = "✅"
-		} else if result.WorkflowResult == githubops.WorkflowResultFailure {
-			icon = "❌"
-		}
-		commentMessage += fmt.Sprintf(`%s ([#%s](%s)) [%s](# "%s")`, result.WorkflowName, result.WorkflowID, url, icon,
-			result.WorkflowResult)
-		if len(result.WorkflowArtifacts) > 0 {
-			commentMessage += "  \n\t Artifacts:"
-			for _, artifact := range result.WorkflowArtifacts {
-				commentMessage += fmt.Sprintf("  \n\t\t - %s ([#%s](%s))", artifact.Name, artifact.Id,
-					artifact.Url)
-			}
-		}
+	return "⚠️️"
+}
+
+// formatDuration formats d in the most compact way for a comment, e.g. 30m instead of 30m0s.
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d >= time.Minute && d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
 	}
-	return commentMessage
+	return d.String()
 }
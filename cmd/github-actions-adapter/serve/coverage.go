@@ -0,0 +1,106 @@
+package serve
+
+import (
+	"context"
+	"path"
+	"radicle-github-actions-adapter/app"
+	"radicle-github-actions-adapter/app/broker"
+	"time"
+)
+
+// patchTargetDelegates is the target of patches to be merged in the default branch of the repo.
+const patchTargetDelegates = "delegates"
+
+// addCoverage adds to the response the coverage of the reports in the workflows' artifacts matching the repo's
+// CoverageArtifacts and compares it with the latest coverage recorded for the base branch. A coverage below the
+// repo's CoverageThreshold fails the response. Failing to fetch the coverage of an artifact is only logged.
+func (gas *GitHubActionsServer) addCoverage(ctx context.Context, brokerRequestMessage *broker.RequestMessage,
+	gitHubActionsSettings *app.GitHubActionsSettings, resultResponse *broker.ResponseMessage) {
+	if len(gitHubActionsSettings.CoverageArtifacts) == 0 {
+		return
+	}
+	var covered, total int
+	for _, workflowDetails := range resultResponse.ResultDetails {
+		for _, artifact := range workflowDetails.WorkflowArtifacts {
+			matched, err := path.Match(gitHubActionsSettings.CoverageArtifacts, artifact.Name)
+			if err != nil {
+				gas.App.Logger.Warn("invalid coverage artifacts pattern", "pattern",
+					gitHubActionsSettings.CoverageArtifacts, "error", err.Error())
+				return
+			}
+			if !matched {
+				continue
+			}
+			coverage, err := gas.GitHubActions.GetArtifactCoverage(ctx, gitHubActionsSettings.GitHubUsername,
+				gitHubActionsSettings.GitHubRepo, artifact.Id)
+			if err != nil {
+				gas.App.Logger.Warn("could not get coverage of artifact", "artifact", artifact.Name, "error",
+					err.Error())
+				continue
+			}
+			covered += coverage.Covered
+			total += coverage.Total
+		}
+	}
+	if total == 0 {
+		return
+	}
+	percent := float64(covered) * 100 / float64(total)
+	resultResponse.Coverage = &broker.Coverage{
+		Covered:    covered,
+		Total:      total,
+		Percent:    percent,
+		BaseBranch: coverageBaseBranch(brokerRequestMessage),
+		Threshold:  gitHubActionsSettings.CoverageThreshold,
+	}
+	basePercent := gas.lastCoverage(ctx, brokerRequestMessage.Repo, resultResponse.Coverage.BaseBranch)
+	if basePercent != nil {
+		delta := percent - *basePercent
+		resultResponse.Coverage.BasePercent, resultResponse.Coverage.Delta = basePercent, &delta
+	}
+	gas.job.Coverage = &percent
+	if percent < gitHubActionsSettings.CoverageThreshold {
+		gas.App.Logger.Info("coverage below threshold", "coverage", percent, "threshold",
+			gitHubActionsSettings.CoverageThreshold)
+		resultResponse.Result = app.BrokerResultFailure
+	}
+}
+
+// coverageBaseBranch returns the branch the coverage of the request is compared with: the target branch of a patch,
+// or the pushed branch, whose previous push is the base.
+func coverageBaseBranch(brokerRequestMessage *broker.RequestMessage) string {
+	if brokerRequestMessage.PatchEvent == nil {
+		return pushedBranch(brokerRequestMessage)
+	}
+	target := brokerRequestMessage.PatchEvent.Patch.Target
+	if target == patchTargetDelegates {
+		return brokerRequestMessage.PatchEvent.Repository.DefaultBranch
+	}
+	return target
+}
+
+// lastCoverage returns the coverage recorded by the latest other push job of the same repo and branch, if any.
+func (gas *GitHubActionsServer) lastCoverage(ctx context.Context, repo, branch string) *float64 {
+	if gas.JobStore == nil || len(branch) == 0 {
+		return nil
+	}
+	storedJobs, err := gas.JobStore.List(ctx)
+	if err != nil {
+		gas.App.Logger.Warn("could not list stored jobs", "error", err.Error())
+		return nil
+	}
+	var coverage *float64
+	var latest *time.Time
+	for _, job := range storedJobs {
+		if job.ID == gas.job.ID || job.Repo != repo || len(job.PatchID) > 0 || job.Branch != branch ||
+			job.Coverage == nil {
+			continue
+		}
+		if latest == nil || job.CreatedAt.After(*latest) {
+			createdAt := job.CreatedAt
+			latest = &createdAt
+			coverage = job.Coverage
+		}
+	}
+	return coverage
+}
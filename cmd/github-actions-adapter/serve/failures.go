@@ -0,0 +1,112 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"radicle-github-actions-adapter/app"
+	"radicle-github-actions-adapter/app/broker"
+	"strings"
+	"time"
+)
+
+// pushedBranch returns the name of the branch of a push event without its refs/heads/ prefix.
+func pushedBranch(brokerRequestMessage *broker.RequestMessage) string {
+	if brokerRequestMessage.PushEvent == nil {
+		return ""
+	}
+	return strings.TrimPrefix(brokerRequestMessage.PushEvent.Branch, "refs/heads/")
+}
+
+// isDefaultBranchPush reports whether the request is a push to the default branch of the repo.
+func isDefaultBranchPush(brokerRequestMessage *broker.RequestMessage) bool {
+	return brokerRequestMessage.EventKind() == broker.EventKindPush &&
+		pushedBranch(brokerRequestMessage) == brokerRequestMessage.PushEvent.Repository.DefaultBranch
+}
+
+// updateFailureIssue reports the result of a push to the default branch on the repo's failure issue, when
+// DefaultBranchFailureIssues is set. A failure opens the issue, or reopens and comments on the one opened before,
+// while a success comments on and closes an open issue. The state of the issue is read from Radicle, only the issue
+// is recorded in the job, so that the next push to the default branch finds it.
+func (gas *GitHubActionsServer) updateFailureIssue(ctx context.Context, brokerRequestMessage *broker.RequestMessage,
+	resultResponse broker.ResponseMessage, gitHubActionsSettings app.GitHubActionsSettings) {
+	if !gas.App.Config.DefaultBranchFailureIssues || gas.Issues == nil || !isDefaultBranchPush(brokerRequestMessage) {
+		return
+	}
+	issueID := gas.lastFailureIssue(ctx, brokerRequestMessage)
+	failed := resultResponse.Result == app.BrokerResultFailure
+	branch := pushedBranch(brokerRequestMessage)
+	open := false
+	var err error
+	if len(issueID) > 0 {
+		open, err = gas.Issues.IssueOpen(ctx, brokerRequestMessage.Repo, issueID)
+		if err != nil {
+			// The issue is kept so that the next push to the default branch retries the update.
+			gas.App.Logger.Warn("could not get failure issue state", "issue_id", issueID, "branch", branch, "error",
+				err.Error())
+			gas.job.FailureIssueID = issueID
+			return
+		}
+	}
+	switch {
+	case failed && len(issueID) == 0:
+		issueID, err = gas.Issues.CreateIssue(ctx, brokerRequestMessage.Repo, "CI failing on "+branch,
+			gas.prepareFailureIssueMessage(brokerRequestMessage, resultResponse, gitHubActionsSettings))
+	case failed:
+		if !open {
+			err = gas.Issues.SetIssueOpen(ctx, brokerRequestMessage.Repo, issueID, true)
+		}
+		if err == nil {
+			_, err = gas.Issues.AddIssueComment(ctx, brokerRequestMessage.Repo, issueID,
+				gas.prepareFailureIssueMessage(brokerRequestMessage, resultResponse, gitHubActionsSettings))
+		}
+	case open:
+		_, err = gas.Issues.AddIssueComment(ctx, brokerRequestMessage.Repo, issueID,
+			fmt.Sprintf("Push of `%s` to `%s` passed ✅", brokerRequestMessage.Commit, branch))
+		if err == nil {
+			err = gas.Issues.SetIssueOpen(ctx, brokerRequestMessage.Repo, issueID, false)
+		}
+	}
+	if err != nil {
+		// The issue is kept so that the next push to the default branch retries the update.
+		gas.App.Logger.Warn("could not update failure issue", "issue_id", issueID, "branch", branch, "error",
+			err.Error())
+		gas.job.FailureIssueID = issueID
+		return
+	}
+	gas.App.Logger.Debug("updated failure issue", "issue_id", issueID, "branch", branch, "open", failed)
+	gas.job.FailureIssueID = issueID
+}
+
+// lastFailureIssue returns the failure issue recorded by the latest other job of the same repo and branch.
+func (gas *GitHubActionsServer) lastFailureIssue(ctx context.Context,
+	brokerRequestMessage *broker.RequestMessage) string {
+	if gas.JobStore == nil {
+		return ""
+	}
+	storedJobs, err := gas.JobStore.List(ctx)
+	if err != nil {
+		gas.App.Logger.Warn("could not list stored jobs", "error", err.Error())
+		return ""
+	}
+	issueID := ""
+	var latest *time.Time
+	for _, job := range storedJobs {
+		if job.ID == gas.job.ID || job.Repo != brokerRequestMessage.Repo ||
+			job.Branch != pushedBranch(brokerRequestMessage) || len(job.FailureIssueID) == 0 {
+			continue
+		}
+		if latest == nil || job.CreatedAt.After(*latest) {
+			createdAt := job.CreatedAt
+			latest = &createdAt
+			issueID = job.FailureIssueID
+		}
+	}
+	return issueID
+}
+
+// prepareFailureIssueMessage describes a failed push to the default branch with its workflows' results.
+func (gas *GitHubActionsServer) prepareFailureIssueMessage(brokerRequestMessage *broker.RequestMessage,
+	resultResponse broker.ResponseMessage, gitHubActionsSettings app.GitHubActionsSettings) string {
+	return fmt.Sprintf("Push of `%s` to `%s` failed  \n %s", brokerRequestMessage.Commit,
+		pushedBranch(brokerRequestMessage), gas.preparePatchCommentResultMessage(resultResponse, gitHubActionsSettings))
+}
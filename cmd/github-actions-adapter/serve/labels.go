@@ -0,0 +1,46 @@
+package serve
+
+import (
+	"context"
+	"radicle-github-actions-adapter/app"
+	"radicle-github-actions-adapter/app/broker"
+	"slices"
+)
+
+// labelPatch applies label to the patch under test in place of the other configured CI labels. An empty label only
+// removes them. The patch is updated only when its labels change.
+func (gas *GitHubActionsServer) labelPatch(ctx context.Context, brokerRequestMessage *broker.RequestMessage,
+	label string) {
+	ciLabels := gas.App.Config.PatchLabels.All()
+	if brokerRequestMessage.PatchEvent == nil || len(ciLabels) == 0 {
+		return
+	}
+	labels := []string{}
+	for _, patchLabel := range gas.patchLabels {
+		if !slices.Contains(ciLabels, patchLabel) {
+			labels = append(labels, patchLabel)
+		}
+	}
+	if len(label) > 0 {
+		labels = append(labels, label)
+	}
+	if slices.Equal(labels, gas.patchLabels) {
+		return
+	}
+	err := gas.Radicle.SetLabels(ctx, brokerRequestMessage.Repo, brokerRequestMessage.PatchEvent.Patch.ID, labels)
+	if err != nil {
+		gas.App.Logger.Warn("could not label patch", "patch_id", brokerRequestMessage.PatchEvent.Patch.ID, "labels",
+			labels, "error", err.Error())
+		return
+	}
+	gas.App.Logger.Debug("labeled patch", "patch_id", brokerRequestMessage.PatchEvent.Patch.ID, "labels", labels)
+	gas.patchLabels = labels
+}
+
+// resultLabel returns the configured label of a finished check's result.
+func (gas *GitHubActionsServer) resultLabel(result string) string {
+	if result == app.BrokerResultSuccess {
+		return gas.App.Config.PatchLabels.Passing
+	}
+	return gas.App.Config.PatchLabels.Failing
+}
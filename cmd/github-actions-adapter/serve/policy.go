@@ -0,0 +1,102 @@
+package serve
+
+import (
+	"context"
+	"radicle-github-actions-adapter/app"
+	"radicle-github-actions-adapter/app/broker"
+	"radicle-github-actions-adapter/app/jobs"
+)
+
+// eventPolicy returns the configured policy for the kind of the request's event.
+func (gas *GitHubActionsServer) eventPolicy(brokerRequestMessage *broker.RequestMessage) app.EventPolicy {
+	policies := gas.App.Config.EventPolicies
+	if policies == nil {
+		policies = app.DefaultEventPolicies
+	}
+	if policy, ok := policies[brokerRequestMessage.EventKind()]; ok {
+		return policy
+	}
+	return app.EventPolicyCheck
+}
+
+// serveWithoutCheck replies to the broker without checking GitHub. The finished response is flagged as skipped, which
+// protocol version 1 can only report as a successful result. With app.EventPolicyCleanup the comments of the patch's
+// previous jobs are redacted first.
+func (gas *GitHubActionsServer) serveWithoutCheck(ctx context.Context, brokerRequestMessage *broker.RequestMessage,
+	policy app.EventPolicy) error {
+	gas.App.Logger.Info("skipping github workflows check", "event", brokerRequestMessage.EventKind(), "policy",
+		policy)
+	jobResponse := broker.ResponseMessage{
+		Response: app.BrokerResponseTriggered,
+		RunID: &broker.RunID{
+			ID: gas.job.ID,
+		},
+		InfoURL: gas.infoURL(gas.job.ID, brokerRequestMessage.Commit, nil),
+	}
+	err := gas.Broker.ServeResponse(ctx, jobResponse)
+	if err != nil {
+		gas.App.Logger.Error("could not send response message to broker", "error", err.Error())
+		return err
+	}
+	if policy == app.EventPolicyCleanup && brokerRequestMessage.PatchEvent != nil {
+		gas.cleanupPatchComments(ctx, brokerRequestMessage)
+	}
+	gas.job.Phase = jobs.JobPhaseFinished
+	gas.job.Result = jobs.JobResultSkipped
+	gas.saveJob(ctx)
+	resultResponse := broker.ResponseMessage{
+		Response: app.BrokerResponseFinished,
+		Result:   app.BrokerResultSuccess,
+		InfoURL:  jobResponse.InfoURL,
+		Skipped:  true,
+	}
+	err = gas.Broker.ServeResponse(ctx, resultResponse)
+	if err != nil {
+		gas.App.Logger.Error("could not send response message to broker", "error", err.Error())
+		return err
+	}
+	return nil
+}
+
+// cleanupPatchComments redacts the comments that previous jobs added on the patch. Jobs of the same revision share
+// its comment, which is redacted once and then forgotten by all of them. Failures are only logged so that they are
+// retried by the next cleanup.
+func (gas *GitHubActionsServer) cleanupPatchComments(ctx context.Context,
+	brokerRequestMessage *broker.RequestMessage) {
+	if gas.JobStore == nil {
+		gas.App.Logger.Warn("could not clean up patch comments", "error", "no job store configured")
+		return
+	}
+	storedJobs, err := gas.JobStore.List(ctx)
+	if err != nil {
+		gas.App.Logger.Warn("could not list stored jobs", "error", err.Error())
+		return
+	}
+	redacted := map[string]bool{}
+	for _, job := range storedJobs {
+		if job.ID == gas.job.ID || job.Repo != brokerRequestMessage.Repo ||
+			job.PatchID != brokerRequestMessage.PatchEvent.Patch.ID || len(job.CommentID) == 0 {
+			continue
+		}
+		done, found := redacted[job.CommentID]
+		if !found {
+			err = gas.Radicle.RedactComment(ctx, job.Repo, job.PatchID, job.RevisionID, job.CommentID)
+			done = err == nil
+			redacted[job.CommentID] = done
+			if err != nil {
+				gas.App.Logger.Warn("could not redact patch comment", "id", job.ID, "comment_id", job.CommentID,
+					"error", err.Error())
+			} else {
+				gas.App.Logger.Debug("redacted patch comment", "id", job.ID, "comment_id", job.CommentID)
+			}
+		}
+		if !done {
+			continue
+		}
+		job.CommentID = ""
+		err = gas.JobStore.Save(ctx, job)
+		if err != nil {
+			gas.App.Logger.Warn("could not save job", "id", job.ID, "error", err.Error())
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"radicle-github-actions-adapter/app"
+	"radicle-github-actions-adapter/app/broker"
+	"radicle-github-actions-adapter/app/jobs"
+	"time"
+)
+
+// Reconcile scans the persisted jobs that never finished and are older than the job timeout (see jobTimeout) plus
+// ShutdownGraceSecs and app.JobTimeoutMargin, so that jobs whose adapter may still be running are left alone.
+// For each one it checks GitHub for the final workflows' results and updates the job's patch or issue comment either
+// with the results or with a note that the adapter aborted. Jobs past JobsRetentionDays are pruned afterwards.
+func (gas *GitHubActionsServer) Reconcile(ctx context.Context) error {
+	if gas.JobStore == nil {
+		return errors.New("no job store configured")
+	}
+	storedJobs, err := gas.JobStore.List(ctx)
+	if err != nil {
+		gas.App.Logger.Error("could not list stored jobs", "error", err.Error())
+		return err
+	}
+	cutoff := time.Now().Add(-(gas.jobTimeout() + time.Second*time.Duration(gas.App.Config.ShutdownGraceSecs) +
+		app.JobTimeoutMargin))
+	var errs []error
+	for _, job := range storedJobs {
+		if job.Phase != jobs.JobPhaseTriggered && job.Phase != jobs.JobPhaseWaiting {
+			continue
+		}
+		if job.CreatedAt.After(cutoff) {
+			gas.App.Logger.Debug("skipping job still within job timeout", "id", job.ID)
+			continue
+		}
+		err = gas.reconcileJob(ctx, job)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("job %s: %w", job.ID, err))
+		}
+	}
+	gas.pruneJobs(ctx, storedJobs)
+	return errors.Join(errs...)
+}
+
+// pruneJobs deletes the finished and aborted jobs not updated for JobsRetentionDays, if set, with their progress log.
+// The push jobs that the next push of each repo and branch reads are kept, see keptPushJobs.
+func (gas *GitHubActionsServer) pruneJobs(ctx context.Context, storedJobs []jobs.Job) {
+	if gas.App.Config.JobsRetentionDays == 0 {
+		return
+	}
+	kept := keptPushJobs(storedJobs)
+	cutoff := time.Now().Add(-24 * time.Hour * time.Duration(gas.App.Config.JobsRetentionDays))
+	var pruned int
+	for _, job := range storedJobs {
+		if (job.Phase != jobs.JobPhaseFinished && job.Phase != jobs.JobPhaseAborted) || job.UpdatedAt.After(cutoff) ||
+			kept[job.ID] {
+			continue
+		}
+		if gas.ProgressLog != nil {
+			err := gas.ProgressLog.DeleteProgress(ctx, job.ID)
+			if err != nil {
+				// The job is kept so that the next run retries deleting its progress log.
+				gas.App.Logger.Warn("could not prune job progress", "id", job.ID, "error", err.Error())
+				continue
+			}
+		}
+		err := gas.JobStore.Delete(ctx, job.ID)
+		if err != nil {
+			gas.App.Logger.Warn("could not prune job", "id", job.ID, "error", err.Error())
+			continue
+		}
+		pruned++
+	}
+	gas.App.Logger.Info("pruned jobs", "count", pruned, "retention_days", gas.App.Config.JobsRetentionDays)
+}
+
+// keptPushJobs returns the IDs of the latest push job of each repo and branch, and of the latest ones recording a
+// failure issue and a coverage, which lastFailureIssue and lastCoverage read.
+func keptPushJobs(storedJobs []jobs.Job) map[string]bool {
+	latestPushJobs := map[string]jobs.Job{}
+	keep := func(key string, job jobs.Job) {
+		if latest, found := latestPushJobs[key]; !found || job.CreatedAt.After(latest.CreatedAt) {
+			latestPushJobs[key] = job
+		}
+	}
+	for _, job := range storedJobs {
+		if len(job.PatchID) > 0 || len(job.Branch) == 0 {
+			continue
+		}
+		key := job.Repo + " " + job.Branch
+		keep(key, job)
+		if len(job.FailureIssueID) > 0 {
+			keep(key+" failure issue", job)
+		}
+		if job.Coverage != nil {
+			keep(key+" coverage", job)
+		}
+	}
+	kept := map[string]bool{}
+	for _, job := range latestPushJobs {
+		kept[job.ID] = true
+	}
+	return kept
+}
+
+// reconcileJob resolves the final state of a single orphaned job.
+// The job is stored as reconciled only if its patch or issue comment (if any) was updated successfully, so that a
+// failed attempt is retried on the next run.
+func (gas *GitHubActionsServer) reconcileJob(ctx context.Context, job jobs.Job) error {
+	gas.App.Logger.Info("reconciling orphaned job", "id", job.ID, "repo", job.Repo, "commit", job.Commit)
+	job.Phase = jobs.JobPhaseAborted
+	job.Result = app.BrokerResultFailure
+	commentMessage := gas.prepareAbortedCommentMessage(job)
+	if len(job.GitHubUsername) > 0 && len(job.GitHubRepo) > 0 {
+		gitHubActionsSettings := app.GitHubActionsSettings{
+			GitHubUsername: job.GitHubUsername,
+			GitHubRepo:     job.GitHubRepo,
+			Events:         job.Events,
+			HeadBranch:     job.HeadBranch,
+		}
+		gas.loadCommentTemplate(job.Repo, &gitHubActionsSettings)
+		workflowsResult, err := gas.GitHubActions.GetRepoCommitWorkflowsResults(ctx, job.GitHubUsername,
+			job.GitHubRepo, job.Commit, gitHubActionsSettings.WorkflowRunsFilter())
+		if err != nil {
+			gas.App.Logger.Warn("could not get repo commit workflows", "id", job.ID, "error", err.Error())
+		} else if workflowsCompleted(workflowsResult) {
+			resultResponse := broker.ResponseMessage{
+				Response: app.BrokerResponseFinished,
+				Result:   app.BrokerResultSuccess,
+			}
+			gas.updateResponseResults(&resultResponse, workflowsResult, nil)
+			commentMessage = gas.preparePatchCommentResultMessage(resultResponse, gitHubActionsSettings)
+			job.Phase = jobs.JobPhaseFinished
+			job.Result = resultResponse.Result
+		}
+	}
+	if len(job.PatchID) > 0 && len(job.RevisionID) > 0 && len(job.CommentID) > 0 {
+		err := gas.Radicle.EditComment(ctx, job.Repo, job.PatchID, job.RevisionID, job.CommentID, commentMessage)
+		if err != nil {
+			gas.App.Logger.Error("could not update patch comment", "id", job.ID, "patch_id", job.PatchID,
+				"comment_id", job.CommentID, "error", err.Error())
+			return err
+		}
+	}
+	if len(job.IssueID) > 0 && len(job.CommentID) > 0 && gas.Issues != nil {
+		commentMessage = pushCommentMessage(job.Commit, job.Branch, commentMessage)
+		err := gas.Issues.EditIssueComment(ctx, job.Repo, job.IssueID, job.CommentID, commentMessage)
+		if err != nil {
+			gas.App.Logger.Error("could not update issue comment", "id", job.ID, "issue_id", job.IssueID,
+				"comment_id", job.CommentID, "error", err.Error())
+			return err
+		}
+	}
+	gas.App.Logger.Info("reconciled job", "id", job.ID, "phase", job.Phase, "result", job.Result)
+	return gas.JobStore.Save(ctx, job)
+}
+
+// prepareAbortedCommentMessage prepares a patch comment for a job that the adapter could not complete.
+func (gas *GitHubActionsServer) prepareAbortedCommentMessage(job jobs.Job) string {
+	commentMessage := "GitHub Actions Result: adapter aborted ⚠️"
+	commentMessage += "  \n *The adapter stopped before the workflows' results were collected."
+	if len(job.GitHubUsername) > 0 && len(job.GitHubRepo) > 0 {
+		commentMessage += fmt.Sprintf(" Check the [workflows](https://github.com/%s/%s/commit/%s) on GitHub.",
+			job.GitHubUsername, job.GitHubRepo, job.Commit)
+	}
+	commentMessage += "*"
+	return commentMessage
+}
@@ -2,21 +2,48 @@ package serve
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net/url"
 	"radicle-github-actions-adapter/app"
 	"radicle-github-actions-adapter/app/broker"
 	"radicle-github-actions-adapter/app/githubops"
+	"radicle-github-actions-adapter/app/jobs"
+	"radicle-github-actions-adapter/app/metrics"
 	"radicle-github-actions-adapter/app/radicle"
+	"slices"
+	"strings"
 	"time"
 )
 
 type AppConfig struct {
-	RadicleHome             string
-	GitHubPAT               string
-	WorkflowsStartLagSecs   uint64
-	WorkflowsPollTimoutSecs uint64
-	RadicleHttpdURL         string
-	RadicleSessionToken     string
+	RadicleHome                string
+	GitHubPAT                  string
+	WorkflowsStartLagSecs      uint64
+	WorkflowsPollTimoutSecs    uint64
+	RadicleHttpdURL            string
+	RadicleSessionToken        string
+	JobsStateDir               string
+	JobsRetentionDays          uint64
+	StatusPageURL              string
+	StatusListenAddr           string
+	MetricsTextfile            string
+	BrokerStrictParsing        bool
+	PushCheckAllCommits        bool
+	PushMaxCommits             uint64
+	ProgressLogDir             string
+	ShutdownGraceSecs          uint64
+	JobTimeoutSecs             uint64
+	WorkflowsTimeoutNeutral    bool
+	WorkflowsBillableTime      bool
+	ConclusionOutcomes         map[githubops.WorkflowConclusion]githubops.ConclusionOutcome
+	EventPolicies              map[broker.EventKind]app.EventPolicy
+	PushTrackingIssues         map[string]string
+	PatchLabels                app.PatchLabels
+	CommentTemplatesDir        string
+	DefaultBranchFailureIssues bool
+	AnnotationsMaxComments     uint64
 }
 
 type App struct {
@@ -30,23 +57,40 @@ type GitHubActionsServer struct {
 	Broker        broker.Broker
 	GitHubActions app.GitHubActions
 	Radicle       radicle.Patch
+	Issues        radicle.Issue
+	JobStore      jobs.Store
+	ProgressLog   broker.ProgressReporter
+	Metrics       metrics.Metrics
+	job           jobs.Job
+	// patchLabels are the current labels of the patch under test.
+	patchLabels []string
+	// codeAnnotations are the annotations commented on the code of the patch under test.
+	codeAnnotations map[broker.WorkflowAnnotation]bool
 }
 
 // NewGitHubActionsServer returns a pointer to a new GitHub Action Server.
 func NewGitHubActionsServer(config *App, broker broker.Broker,
-	GitHubActions app.GitHubActions, radiclePatrch radicle.Patch) *GitHubActionsServer {
+	GitHubActions app.GitHubActions, radiclePatrch radicle.Patch, radicleIssue radicle.Issue, jobStore jobs.Store,
+	progressLog broker.ProgressReporter, metrics metrics.Metrics) *GitHubActionsServer {
 	server := &GitHubActionsServer{
 		App:           config,
 		Broker:        broker,
 		GitHubActions: GitHubActions,
 		Radicle:       radiclePatrch,
+		Issues:        radicleIssue,
+		JobStore:      jobStore,
+		ProgressLog:   progressLog,
+		Metrics:       metrics,
 	}
 	return server
 }
 
 // Serve is responsible for parsing stdin input and check any GitHub Actions status of radicle projects
 // It also manages replies to the broker.
+// The whole job is bounded by jobTimeout.
 func (gas *GitHubActionsServer) Serve(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, gas.jobTimeout())
+	defer cancel()
 	eventUUID := ctx.Value(app.EventUUIDKey).(string)
 	gas.App.Logger.Info("serving event", app.EventUUIDKey.String(), eventUUID)
 	brokerRequestMessage, err := gas.Broker.ParseRequestMessage(ctx)
@@ -55,11 +99,34 @@ func (gas *GitHubActionsServer) Serve(ctx context.Context) error {
 		return err
 	}
 	gas.App.Logger.Debug("serving broker message", "message", *brokerRequestMessage)
+	gas.job = jobs.Job{
+		ID:     eventUUID,
+		Repo:   brokerRequestMessage.Repo,
+		Commit: brokerRequestMessage.Commit,
+		Phase:  jobs.JobPhaseTriggered,
+		// CreatedAt is set here rather than by the job store, so that the comments can report the wall time
+		// even without one.
+		CreatedAt: time.Now().UTC(),
+	}
+	if brokerRequestMessage.PatchEvent != nil {
+		gas.job.PatchID = brokerRequestMessage.PatchEvent.Patch.ID
+		gas.job.RevisionID = brokerRequestMessage.RevisionID
+		gas.patchLabels = slices.Clone(brokerRequestMessage.PatchEvent.Patch.Labels)
+	}
+	gas.job.Branch = pushedBranch(brokerRequestMessage)
+	gas.job.IssueID = gas.trackingIssue(brokerRequestMessage)
+	gas.saveJob(ctx)
+	defer gas.observeJob(brokerRequestMessage)
+	if policy := gas.eventPolicy(brokerRequestMessage); policy != app.EventPolicyCheck {
+		return gas.serveWithoutCheck(ctx, brokerRequestMessage, policy)
+	}
 	jobResponse := broker.ResponseMessage{
 		Response: app.BrokerResponseTriggered,
 		RunID: &broker.RunID{
 			ID: eventUUID,
 		},
+		// The repo is not cloned yet, so only the status page can be linked to.
+		InfoURL: gas.infoURL(eventUUID, brokerRequestMessage.Commit, nil),
 	}
 	gas.App.Logger.Debug("sending message", "message", jobResponse)
 	err = gas.Broker.ServeResponse(ctx, jobResponse)
@@ -68,16 +135,32 @@ func (gas *GitHubActionsServer) Serve(ctx context.Context) error {
 		return err
 	}
 
-	resultResponse, err := gas.checkGitHubWorkflows(ctx, brokerRequestMessage)
+	resultResponse, repoCommitWorkflowSetup, err := gas.checkGitHubWorkflows(ctx, brokerRequestMessage)
+	if err != nil && ctx.Err() != nil {
+		gas.handleInterruption(ctx, brokerRequestMessage)
+		return err
+	}
 	if err != nil {
-		//In case of an error append to the comment patch
-		if brokerRequestMessage.PatchEvent != nil {
+		//In case of an error append to the comment
+		if gas.canComment(brokerRequestMessage) {
 			commentMessage := "Could not check GitHub Action Workflows."
 			commentMessage += "\n  *Error Details: " + err.Error() + "*"
-			_ = gas.commentOnPatch(ctx, brokerRequestMessage, commentMessage, true)
+			_ = gas.comment(ctx, brokerRequestMessage, commentMessage, true)
 		}
+		gas.labelPatch(ctx, brokerRequestMessage, gas.App.Config.PatchLabels.Failing)
+		gas.job.Phase = jobs.JobPhaseFinished
+		gas.job.Result = app.BrokerResultFailure
+		gas.saveJob(ctx)
 		return err
 	}
+	resultResponse.InfoURL = gas.infoURL(eventUUID, brokerRequestMessage.Commit, repoCommitWorkflowSetup)
+	gas.labelPatch(ctx, brokerRequestMessage, gas.resultLabel(resultResponse.Result))
+	gas.job.Phase = jobs.JobPhaseFinished
+	gas.job.Result = resultResponse.Result
+	if repoCommitWorkflowSetup != nil {
+		gas.updateFailureIssue(ctx, brokerRequestMessage, resultResponse, *repoCommitWorkflowSetup)
+	}
+	gas.saveJob(ctx)
 
 	gas.App.Logger.Debug("sending message", "message", resultResponse)
 	err = gas.Broker.ServeResponse(ctx, resultResponse)
@@ -88,52 +171,111 @@ func (gas *GitHubActionsServer) Serve(ctx context.Context) error {
 	return nil
 }
 
-func (gas *GitHubActionsServer) checkGitHubWorkflows(ctx context.Context, brokerRequestMessage *broker.RequestMessage) (broker.ResponseMessage, error) {
+// checkGitHubWorkflows clones the repo to load its GitHub Actions settings, if any, and waits for the results of the
+// workflows. It returns the finished response with the settings.
+func (gas *GitHubActionsServer) checkGitHubWorkflows(ctx context.Context,
+	brokerRequestMessage *broker.RequestMessage) (broker.ResponseMessage, *app.GitHubActionsSettings, error) {
 	repoCommitWorkflowSetup, err := gas.GitHubActions.GetRepoCommitWorkflowSetup(ctx, brokerRequestMessage.Repo,
 		brokerRequestMessage.Commit)
 	if err != nil {
 		gas.App.Logger.Error("could not fetch github workflows setup", "error", err.Error())
-		return broker.ResponseMessage{}, err
+		return broker.ResponseMessage{}, nil, err
 	}
+	gas.loadCommentTemplate(brokerRequestMessage.Repo, repoCommitWorkflowSetup)
 	resultResponse := broker.ResponseMessage{
 		Response: app.BrokerResponseFinished,
 		Result:   app.BrokerResultSuccess,
 	}
 	if repoCommitWorkflowSetup != nil {
+		gas.job.GitHubUsername = repoCommitWorkflowSetup.GitHubUsername
+		gas.job.GitHubRepo = repoCommitWorkflowSetup.GitHubRepo
+		gas.job.Events = repoCommitWorkflowSetup.Events
+		gas.job.HeadBranch = repoCommitWorkflowSetup.HeadBranch
+		gas.job.Phase = jobs.JobPhaseWaiting
+		gas.saveJob(ctx)
+		gas.labelPatch(ctx, brokerRequestMessage, gas.App.Config.PatchLabels.Pending)
 		// Write 1st comment that we check GitHub for workflows
-		if brokerRequestMessage.PatchEvent != nil {
+		if gas.canComment(brokerRequestMessage) {
 			commentMessage := "Checking for GitHub Actions Workflows..."
-			_ = gas.commentOnPatch(ctx, brokerRequestMessage, commentMessage, false)
+			_ = gas.comment(ctx, brokerRequestMessage, commentMessage, false)
+		}
+		err = sleep(ctx, time.Second*time.Duration(gas.App.Config.WorkflowsStartLagSecs))
+		if err != nil {
+			gas.App.Logger.Warn("stopped waiting for github workflows to start", "error", err.Error())
+			return broker.ResponseMessage{}, nil, err
 		}
-		time.Sleep(time.Second * time.Duration(gas.App.Config.WorkflowsStartLagSecs))
 
 		//Wait for GitHub Workflows results and write comment and update the existing comment
-		workflowsResult, err := gas.waitRepoCommitWorkflows(ctx, repoCommitWorkflowSetup, brokerRequestMessage)
+		commits := gas.commitsToCheck(brokerRequestMessage)
+		workflowsResult, timedOut, err := gas.waitRepoCommitWorkflows(ctx, repoCommitWorkflowSetup,
+			brokerRequestMessage, commits)
 		if err != nil {
-			gas.App.Logger.Error("failed waiting for github workflows")
-			return broker.ResponseMessage{}, err
+			gas.App.Logger.Error("failed waiting for github workflows", "commits", commits)
+			return broker.ResponseMessage{}, nil, err
 		}
 		//Update the comment with the final results of the workflows
-		gas.updateResponseResults(&resultResponse, workflowsResult)
-		if brokerRequestMessage.PatchEvent != nil {
+		resultResponse.TimedOut = timedOut
+		gas.updateResponseResults(&resultResponse, workflowsResult, commits)
+		gas.addAnnotations(ctx, repoCommitWorkflowSetup, &resultResponse)
+		gas.addTestSummaries(ctx, repoCommitWorkflowSetup, &resultResponse)
+		gas.addCoverage(ctx, brokerRequestMessage, repoCommitWorkflowSetup, &resultResponse)
+		gas.commentAnnotations(ctx, brokerRequestMessage, resultResponse)
+		if gas.canComment(brokerRequestMessage) {
 			commentMessage := gas.preparePatchCommentResultMessage(resultResponse, *repoCommitWorkflowSetup)
-			_ = gas.commentOnPatch(ctx, brokerRequestMessage, commentMessage, false)
+			_ = gas.comment(ctx, brokerRequestMessage, commentMessage, false)
 		}
 	}
-	return resultResponse, nil
+	return resultResponse, repoCommitWorkflowSetup, nil
 }
 
-// updateResponseResults
+// updateResponseResults adds the workflows' details to the response and marks it as failed if the conclusion of any
+// workflow has a fail outcome. When the workflows of several commits are checked, the result of each commit is
+// reported too, app.CommitResultNoWorkflows for the commits without any workflow run.
+// When the response has timed out, workflows still running are reported as app.WorkflowResultStillRunning and fail
+// the response unless WorkflowsTimeoutNeutral is set.
 func (gas *GitHubActionsServer) updateResponseResults(resultResponse *broker.ResponseMessage, workflowsResult []app.
-	WorkflowResult) {
+	WorkflowResult, commits []string) {
+	commitResults := map[string]string{}
+	for _, commit := range commits {
+		commitResults[commit] = app.CommitResultNoWorkflows
+	}
 	for _, workflowResult := range workflowsResult {
 		workflowDetails := broker.WorkflowDetails{
 			WorkflowID:     workflowResult.WorkflowID,
 			WorkflowName:   workflowResult.WorkflowName,
-			WorkflowResult: workflowResult.Result,
+			WorkflowResult: string(workflowResult.Result),
+			WorkflowCommit: workflowResult.Commit,
+		}
+		if result, found := commitResults[workflowResult.Commit]; !found || result == app.CommitResultNoWorkflows {
+			commitResults[workflowResult.Commit] = app.BrokerResultSuccess
 		}
 		if len(workflowDetails.WorkflowResult) == 0 {
-			workflowDetails.WorkflowResult = workflowResult.Status
+			workflowDetails.WorkflowResult = string(workflowResult.Status)
+		}
+		stillRunning := resultResponse.TimedOut && workflowResult.Status != githubops.WorkflowStatusCompleted
+		if stillRunning {
+			workflowDetails.WorkflowResult = app.WorkflowResultStillRunning
+		}
+		workflowDetails.WorkflowAttempt = workflowResult.RunAttempt
+		workflowDetails.WorkflowRunNumber = workflowResult.RunNumber
+		workflowDetails.WorkflowEvent = workflowResult.Event
+		workflowDetails.WorkflowHeadBranch = workflowResult.HeadBranch
+		workflowDetails.WorkflowCreatedAt = optionalTime(workflowResult.CreatedAt)
+		workflowDetails.WorkflowStartedAt = optionalTime(workflowResult.StartedAt)
+		workflowDetails.WorkflowUpdatedAt = optionalTime(workflowResult.UpdatedAt)
+		workflowDetails.WorkflowURL = workflowResult.HTMLURL
+		workflowDetails.WorkflowBillableMS = workflowResult.BillableMS
+		for _, attempt := range workflowResult.PreviousAttempts {
+			attemptDetails := broker.WorkflowAttempt{
+				RunID:      attempt.RunID,
+				RunAttempt: attempt.RunAttempt,
+				Result:     string(attempt.Result),
+				Url:        attempt.Url,
+			}
+			if len(attemptDetails.Result) == 0 {
+				attemptDetails.Result = string(attempt.Status)
+			}
+			workflowDetails.PreviousAttempts = append(workflowDetails.PreviousAttempts, attemptDetails)
 		}
 		for _, artifact := range workflowResult.Artifacts {
 			workflowDetails.WorkflowArtifacts = append(workflowDetails.WorkflowArtifacts, broker.WorkflowArtifact{
@@ -144,49 +286,325 @@ func (gas *GitHubActionsServer) updateResponseResults(resultResponse *broker.Res
 			})
 		}
 		resultResponse.ResultDetails = append(resultResponse.ResultDetails, workflowDetails)
+		if stillRunning && gas.App.Config.WorkflowsTimeoutNeutral {
+			continue
+		}
 		if resultResponse.Response == app.BrokerResponseFinished &&
-			workflowResult.Result != githubops.WorkflowResultSuccess {
+			gas.conclusionOutcome(workflowResult.Result) == githubops.ConclusionOutcomeFail {
 			resultResponse.Result = app.BrokerResultFailure
+			commitResults[workflowResult.Commit] = app.BrokerResultFailure
 		}
 	}
+	if len(commitResults) > 1 && resultResponse.Response == app.BrokerResponseFinished {
+		resultResponse.CommitResults = commitResults
+	}
 }
 
-// waitRepoCommitWorkflows waits for all workflows to complete execution and returns their results.
-// Wait time is upper bounded by WorkflowCheckInterval.
-func (gas *GitHubActionsServer) waitRepoCommitWorkflows(ctx context.Context,
-	repoCommitWorkflowSetup *app.GitHubActionsSettings, brokerRequestMessage *broker.RequestMessage) ([]app.
-	WorkflowResult, error) {
-	var workflowsResult []app.WorkflowResult
-	var err error
-	for start := time.Now(); time.Since(start) < time.Second*time.Duration(gas.App.Config.WorkflowsPollTimoutSecs); {
-		workflowsCompleted := true
-		workflowsResult, err = gas.GitHubActions.GetRepoCommitWorkflowsResults(ctx, repoCommitWorkflowSetup.GitHubUsername,
-			repoCommitWorkflowSetup.GitHubRepo, brokerRequestMessage.Commit)
-		if err != nil {
-			gas.App.Logger.Error("could not get repo commit workflows", "error", err.Error())
-			return nil, err
-		}
+// conclusionOutcome returns how a workflow conclusion affects the result, according to ConclusionOutcomes or
+// githubops.DefaultConclusionOutcomes if not configured. Unknown conclusions fail.
+func (gas *GitHubActionsServer) conclusionOutcome(conclusion githubops.WorkflowConclusion) githubops.ConclusionOutcome {
+	outcomes := gas.App.Config.ConclusionOutcomes
+	if outcomes == nil {
+		outcomes = githubops.DefaultConclusionOutcomes
+	}
+	if outcome, ok := outcomes[conclusion]; ok {
+		return outcome
+	}
+	return githubops.ConclusionOutcomeFail
+}
 
-		for _, workflowResult := range workflowsResult {
-			if workflowResult.Status != githubops.WorkflowStatusCompleted {
-				workflowsCompleted = false
+// waitRepoCommitWorkflows waits for all workflows of the commits to complete execution and returns their results, in
+// the order of the commits. Each poll queries the commits whose workflows have not completed yet.
+// Wait time is upper bounded by WorkflowsPollTimoutSecs, shared by all the commits. Once that elapses the latest
+// results are returned and timedOut is true, while the cancellation of ctx itself is returned as an error.
+func (gas *GitHubActionsServer) waitRepoCommitWorkflows(ctx context.Context,
+	repoCommitWorkflowSetup *app.GitHubActionsSettings, brokerRequestMessage *broker.RequestMessage, commits []string) (
+	workflowsResult []app.WorkflowResult, timedOut bool, err error) {
+	pollCtx, cancel := context.WithTimeout(ctx, time.Second*time.Duration(gas.App.Config.WorkflowsPollTimoutSecs))
+	defer cancel()
+	workflowsStatus := map[string]string{}
+	commitsResult := make(map[string][]app.WorkflowResult, len(commits))
+	for {
+		for _, commit := range commits {
+			if results, found := commitsResult[commit]; found && workflowsCompleted(results) {
+				continue
+			}
+			results, err := gas.GitHubActions.GetRepoCommitWorkflowsResults(pollCtx,
+				repoCommitWorkflowSetup.GitHubUsername, repoCommitWorkflowSetup.GitHubRepo, commit,
+				repoCommitWorkflowSetup.WorkflowRunsFilter())
+			if err != nil && ctx.Err() == nil && pollCtx.Err() != nil {
+				timedOut = true
 				break
 			}
+			if err != nil {
+				gas.App.Logger.Error("could not get repo commit workflows", "commit", commit, "error", err.Error())
+				return nil, false, err
+			}
+			for i := range results {
+				results[i].Commit = commit
+			}
+			commitsResult[commit] = results
 		}
-		if workflowsCompleted {
+		workflowsResult = nil
+		for _, commit := range commits {
+			workflowsResult = append(workflowsResult, commitsResult[commit]...)
+		}
+		if timedOut {
+			break
+		}
+		gas.reportProgress(ctx, workflowsStatus, workflowsResult)
+		if workflowsCompleted(workflowsResult) {
 			gas.App.Logger.Info("all workflows execution completed")
 			break
 		}
-		if brokerRequestMessage.PatchEvent != nil {
+		if gas.canComment(brokerRequestMessage) {
 			resultResponse := broker.ResponseMessage{
 				Response: app.BrokerResponseInProgress,
 				Result:   app.BrokerResultSuccess,
 			}
-			gas.updateResponseResults(&resultResponse, workflowsResult)
+			gas.updateResponseResults(&resultResponse, workflowsResult, nil)
 			commentMessage := gas.preparePatchCommentResultMessage(resultResponse, *repoCommitWorkflowSetup)
-			_ = gas.commentOnPatch(ctx, brokerRequestMessage, commentMessage, false)
+			_ = gas.comment(ctx, brokerRequestMessage, commentMessage, false)
+		}
+		err = sleep(pollCtx, app.WorkflowCheckInterval)
+		if err != nil && ctx.Err() != nil {
+			gas.App.Logger.Warn("stopped waiting for github workflows", "error", ctx.Err().Error())
+			return nil, false, ctx.Err()
+		}
+		if err != nil {
+			timedOut = true
+			break
+		}
+	}
+	if timedOut {
+		var stillRunning []string
+		for _, workflowResult := range workflowsResult {
+			if workflowResult.Status != githubops.WorkflowStatusCompleted {
+				stillRunning = append(stillRunning, workflowResult.WorkflowName)
+			}
+		}
+		gas.App.Logger.Warn("workflows poll timeout exceeded", "commits", commits, "timeout_secs",
+			gas.App.Config.WorkflowsPollTimoutSecs, "still_running", stillRunning)
+	}
+	return workflowsResult, timedOut, nil
+}
+
+// commitsToCheck returns the commits whose workflows are checked. It is the commit of the request, followed for push
+// events with PushCheckAllCommits by the other pushed commits, newest first and up to PushMaxCommits in total.
+func (gas *GitHubActionsServer) commitsToCheck(brokerRequestMessage *broker.RequestMessage) []string {
+	commits := []string{brokerRequestMessage.Commit}
+	if !gas.App.Config.PushCheckAllCommits || brokerRequestMessage.PushEvent == nil {
+		return commits
+	}
+	pushedCommits := brokerRequestMessage.PushEvent.Commits
+	for i := len(pushedCommits) - 1; i >= 0; i-- {
+		if gas.App.Config.PushMaxCommits > 0 && uint64(len(commits)) >= gas.App.Config.PushMaxCommits {
+			gas.App.Logger.Warn("not checking all pushed commits", "pushed", len(pushedCommits), "max",
+				gas.App.Config.PushMaxCommits)
+			break
+		}
+		if !slices.Contains(commits, pushedCommits[i]) {
+			commits = append(commits, pushedCommits[i])
+		}
+	}
+	return commits
+}
+
+// reportProgress sends the workflows whose status changed since the previous poll to the broker, or to the
+// ProgressLog when the broker's protocol does not support progress messages, and records them in the job.
+// workflowsStatus holds the last reported status of each workflow and is updated. Failures are only logged as progress
+// is informational.
+func (gas *GitHubActionsServer) reportProgress(ctx context.Context, workflowsStatus map[string]string,
+	workflowsResult []app.WorkflowResult) {
+	var changed []broker.WorkflowProgress
+	for _, workflowResult := range workflowsResult {
+		status := string(workflowResult.Result)
+		if len(status) == 0 {
+			status = string(workflowResult.Status)
 		}
-		time.Sleep(app.WorkflowCheckInterval)
+		previousStatus, found := workflowsStatus[workflowResult.WorkflowID]
+		if found && previousStatus == status {
+			continue
+		}
+		workflowsStatus[workflowResult.WorkflowID] = status
+		changed = append(changed, broker.WorkflowProgress{
+			WorkflowID:     workflowResult.WorkflowID,
+			WorkflowName:   workflowResult.WorkflowName,
+			Status:         status,
+			PreviousStatus: previousStatus,
+			WorkflowURL:    workflowResult.HTMLURL,
+		})
+	}
+	if len(changed) == 0 {
+		return
+	}
+	gas.recordWorkflows(ctx, workflowsResult)
+	progressMessage := broker.ProgressMessage{
+		Response:  app.BrokerResponseProgress,
+		RunID:     &broker.RunID{ID: gas.job.ID},
+		Timestamp: time.Now().UTC(),
+		Workflows: changed,
+	}
+	gas.App.Logger.Debug("sending progress message", "message", progressMessage)
+	err := gas.Broker.ServeProgress(ctx, progressMessage)
+	if errors.Is(err, broker.ErrProgressNotSupported) {
+		if gas.ProgressLog == nil {
+			return
+		}
+		err = gas.ProgressLog.ReportProgress(ctx, progressMessage)
+	}
+	if err != nil {
+		gas.App.Logger.Warn("could not report workflows progress", "error", err.Error())
+	}
+}
+
+// recordWorkflows updates the workflows of the job with the status of workflowsResult and persists it, so that the
+// status page shows the progress of the job.
+func (gas *GitHubActionsServer) recordWorkflows(ctx context.Context, workflowsResult []app.WorkflowResult) {
+	if gas.Metrics != nil && len(gas.job.Workflows) == 0 && len(workflowsResult) > 0 {
+		gas.Metrics.ObserveTimeToFirstRun(time.Since(gas.job.CreatedAt))
+	}
+	for _, workflowResult := range workflowsResult {
+		workflow := jobs.JobWorkflow{
+			ID:     workflowResult.WorkflowID,
+			Name:   workflowResult.WorkflowName,
+			Commit: workflowResult.Commit,
+			Status: string(workflowResult.Result),
+			URL:    workflowResult.HTMLURL,
+		}
+		if len(workflow.Status) == 0 {
+			workflow.Status = string(workflowResult.Status)
+		}
+		i := slices.IndexFunc(gas.job.Workflows, func(jobWorkflow jobs.JobWorkflow) bool {
+			return jobWorkflow.ID == workflow.ID
+		})
+		if i < 0 {
+			gas.job.Workflows = append(gas.job.Workflows, workflow)
+			continue
+		}
+		gas.job.Workflows[i] = workflow
+	}
+	gas.saveJob(ctx)
+}
+
+// infoURL returns the page reported to the broker for the run: the adapter's status page of the run when
+// StatusPageURL is configured, otherwise the GitHub checks of the commit when the repo has GitHub Actions settings.
+// The triggered response is sent before the repo is cloned, so it passes nil settings.
+func (gas *GitHubActionsServer) infoURL(runID, commit string, gitHubActionsSettings *app.GitHubActionsSettings) string {
+	if len(gas.App.Config.StatusPageURL) > 0 {
+		return strings.TrimSuffix(gas.App.Config.StatusPageURL, "/") + "/jobs/" + url.PathEscape(runID)
+	}
+	if gitHubActionsSettings == nil || len(gitHubActionsSettings.GitHubUsername) == 0 ||
+		len(gitHubActionsSettings.GitHubRepo) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("https://github.com/%s/%s/commit/%s/checks", gitHubActionsSettings.GitHubUsername,
+		gitHubActionsSettings.GitHubRepo, commit)
+}
+
+// jobTimeout returns the overall deadline of a job. Unless configured, it is derived from the workflows' start lag
+// and poll timeout plus app.JobTimeoutMargin for cloning the repo and reporting the results.
+func (gas *GitHubActionsServer) jobTimeout() time.Duration {
+	if gas.App.Config.JobTimeoutSecs > 0 {
+		return time.Second * time.Duration(gas.App.Config.JobTimeoutSecs)
+	}
+	return time.Second*time.Duration(gas.App.Config.WorkflowsStartLagSecs+gas.App.Config.WorkflowsPollTimoutSecs) +
+		app.JobTimeoutMargin
+}
+
+// handleInterruption rewrites the patch or issue comment, removes the patch's CI labels and stores the job as aborted
+// when the adapter is stopped before the workflows complete. The updates use a context detached from the cancelled
+// one, bounded by ShutdownGraceSecs.
+func (gas *GitHubActionsServer) handleInterruption(ctx context.Context, brokerRequestMessage *broker.RequestMessage) {
+	gas.App.Logger.Warn("github workflows check interrupted", "error", ctx.Err().Error())
+	graceCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx),
+		time.Second*time.Duration(gas.App.Config.ShutdownGraceSecs))
+	defer cancel()
+	if gas.canComment(brokerRequestMessage) {
+		commentMessage := "GitHub Actions Result: interrupted ⚠️"
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			commentMessage += "  \n *The job exceeded its deadline before the workflows completed.*"
+		} else {
+			commentMessage += "  \n *The adapter was stopped before the workflows completed.*"
+		}
+		_ = gas.comment(graceCtx, brokerRequestMessage, commentMessage, false)
+	}
+	gas.labelPatch(graceCtx, brokerRequestMessage, "")
+	gas.job.Phase = jobs.JobPhaseAborted
+	gas.job.Result = app.BrokerResultFailure
+	gas.saveJob(graceCtx)
+}
+
+// sleep pauses the current goroutine for at least the duration d or until ctx is done.
+// It returns the context's error if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// workflowsCompleted reports whether every workflow has completed its execution.
+func workflowsCompleted(workflowsResult []app.WorkflowResult) bool {
+	for _, workflowResult := range workflowsResult {
+		if workflowResult.Status != githubops.WorkflowStatusCompleted {
+			return false
+		}
+	}
+	return true
+}
+
+// saveJob persists the current job state if a job store is configured.
+// Failing to persist the state should never fail the job itself.
+func (gas *GitHubActionsServer) saveJob(ctx context.Context) {
+	if gas.JobStore == nil {
+		return
+	}
+	err := gas.JobStore.Save(ctx, gas.job)
+	if err != nil {
+		gas.App.Logger.Warn("could not persist job state", "id", gas.job.ID, "error", err.Error())
+	}
+}
+
+// observeJob records the job in the metrics, once served, with its result, aborted if interrupted or error if it
+// did not finish, e.g. when the broker could not be replied to.
+func (gas *GitHubActionsServer) observeJob(brokerRequestMessage *broker.RequestMessage) {
+	if gas.Metrics == nil {
+		return
+	}
+	result := gas.job.Result
+	switch gas.job.Phase {
+	case jobs.JobPhaseAborted:
+		result = jobs.JobPhaseAborted
+	case jobs.JobPhaseFinished:
+	default:
+		result = "error"
+	}
+	gas.Metrics.ObserveJob(eventLabel(brokerRequestMessage.EventKind()), result, time.Since(gas.job.CreatedAt))
+}
+
+// eventLabel returns the event kind recorded in the metrics. Patch actions without their own event kind are recorded
+// as patch.other, so that the values of the label stay bounded.
+func eventLabel(kind broker.EventKind) string {
+	switch kind {
+	case broker.EventKindPush, broker.EventKindTag, broker.EventKindBranchDeleted, broker.EventKindPatchCreated,
+		broker.EventKindPatchUpdated, broker.EventKindPatchMerged, broker.EventKindPatchArchived:
+		return string(kind)
+	}
+	if strings.HasPrefix(string(kind), "patch.") {
+		return "patch.other"
+	}
+	return string(kind)
+}
+
+// optionalTime returns nil for the zero time so that unknown timestamps are omitted from the broker response.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
 	}
-	return workflowsResult, nil
+	return &t
 }
@@ -3,18 +3,29 @@ package serve
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
 	"os"
+	"path/filepath"
 	"radicle-github-actions-adapter/app"
 	"radicle-github-actions-adapter/app/broker"
 	"radicle-github-actions-adapter/app/githubops"
+	"radicle-github-actions-adapter/app/jobs"
 	"radicle-github-actions-adapter/app/radicle"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
-type MockBroker struct{}
+type MockBroker struct {
+	ProgressSupported bool
+	Progress          []broker.ProgressMessage
+	Responses         []broker.ResponseMessage
+}
 
 func (mb *MockBroker) ParseRequestMessage(ctx context.Context) (*broker.RequestMessage, error) {
 	eventUUID := ctx.Value(app.EventUUIDKey).(string)
@@ -32,6 +43,7 @@ func (mb *MockBroker) ParseRequestMessage(ctx context.Context) (*broker.RequestM
 				},
 				Before:  "before_commit_hash",
 				After:   commitID,
+				Branch:  "refs/heads/main",
 				Commits: []string{"before_commit_hash", "1"},
 				Repository: broker.Repository{
 					ID:            "repo_id",
@@ -47,9 +59,10 @@ func (mb *MockBroker) ParseRequestMessage(ctx context.Context) (*broker.RequestM
 		return &brokerMessage, nil
 	} else if strings.HasPrefix(eventUUID, "event-uuid-patch") {
 		brokerMessage := broker.RequestMessage{
-			Repo:      "repo_id",
-			Commit:    commitID,
-			PushEvent: nil,
+			Repo:       "repo_id",
+			Commit:     commitID,
+			RevisionID: "revision_id",
+			PushEvent:  nil,
 			PatchEvent: &broker.RequestPatchEventMessage{
 				Request:   "trigger",
 				EventType: "patch",
@@ -69,7 +82,7 @@ func (mb *MockBroker) ParseRequestMessage(ctx context.Context) (*broker.RequestM
 					After:     commitID,
 					Commits:   []string{"before_commit_hash", "1"},
 					Target:    "delegates",
-					Labels:    nil,
+					Labels:    []string{"bug", "ci:failing"},
 					Assignees: nil,
 					Revisions: []broker.PatchRevision{
 						{
@@ -128,9 +141,54 @@ func (mb *MockBroker) ServeResponse(ctx context.Context, responseMessage broker.
 	if strings.Contains(eventUUID, "invalid") {
 		return errors.New("unknown error")
 	}
+	mb.Responses = append(mb.Responses, responseMessage)
+	return nil
+}
+
+func (mb *MockBroker) ServeProgress(ctx context.Context, progressMessage broker.ProgressMessage) error {
+	if !mb.ProgressSupported {
+		return broker.ErrProgressNotSupported
+	}
+	mb.Progress = append(mb.Progress, progressMessage)
+	return nil
+}
+
+type MockProgressLog struct {
+	Progress []broker.ProgressMessage
+	Deleted  []string
+}
+
+func (pl *MockProgressLog) ReportProgress(ctx context.Context, progressMessage broker.ProgressMessage) error {
+	pl.Progress = append(pl.Progress, progressMessage)
 	return nil
 }
 
+func (pl *MockProgressLog) DeleteProgress(ctx context.Context, runID string) error {
+	pl.Deleted = append(pl.Deleted, runID)
+	return nil
+}
+
+type MockMetrics struct {
+	Jobs            []string
+	TimesToFirstRun int
+}
+
+func (m *MockMetrics) ObserveJob(event, result string, duration time.Duration) {
+	m.Jobs = append(m.Jobs, event+" "+result)
+}
+
+func (m *MockMetrics) ObserveTimeToFirstRun(duration time.Duration) {
+	m.TimesToFirstRun++
+}
+
+func (m *MockMetrics) ObserveGitHubAPICall(endpoint string, statusCode int) {}
+
+func (m *MockMetrics) SetGitHubRateLimitRemaining(remaining int) {}
+
+func (m *MockMetrics) ObserveRadicleAction(action string, err error) {}
+
+func (m *MockMetrics) ObserveClone(duration time.Duration) {}
+
 type MockGitHubActions struct{}
 
 func (g *MockGitHubActions) GetRepoCommitWorkflowSetup(ctx context.Context, projectID, commitHash string) (*app.GitHubActionsSettings, error) {
@@ -144,7 +202,8 @@ func (g *MockGitHubActions) GetRepoCommitWorkflowSetup(ctx context.Context, proj
 	}, nil
 }
 
-func (g *MockGitHubActions) GetRepoCommitWorkflowsResults(ctx context.Context, githubUsername, githubRepo, githubCommit string) ([]app.WorkflowResult, error) {
+func (g *MockGitHubActions) GetRepoCommitWorkflowsResults(ctx context.Context, githubUsername, githubRepo, githubCommit string,
+	filter app.WorkflowRunsFilter) ([]app.WorkflowResult, error) {
 	eventUUID := ctx.Value(app.EventUUIDKey).(string)
 	if strings.Contains(eventUUID, "invalid") {
 		return nil, errors.New("unknown error")
@@ -174,39 +233,171 @@ func (g *MockGitHubActions) GetRepoCommitWorkflowsResults(ctx context.Context, g
 	return workflowResults, nil
 }
 
+func (g *MockGitHubActions) GetWorkflowRunAnnotations(ctx context.Context, githubUsername, githubRepo,
+	runID string) ([]app.WorkflowAnnotation, error) {
+	eventUUID := ctx.Value(app.EventUUIDKey).(string)
+	if !strings.Contains(eventUUID, "annotations") {
+		return nil, nil
+	}
+	return []app.WorkflowAnnotation{
+		{Path: "main.go", StartLine: 3, EndLine: 3, Level: "warning", Title: "lint", Message: "unused\nvariable"},
+		{Path: "util.go", StartLine: 10, EndLine: 12, Level: "failure", Message: "test failed"},
+		{Path: ".github", Level: "failure", Message: "Process completed with exit code 1."},
+	}, nil
+}
+
+func (g *MockGitHubActions) GetArtifactTestSummary(ctx context.Context, githubUsername, githubRepo,
+	artifactID string) (*app.TestSummary, error) {
+	switch artifactID {
+	case "1":
+		failedTests := []app.FailedTest{{Name: "pkg.TestFail", Message: "expected 1,\n got 2"}}
+		for i := 0; i < 10; i++ {
+			failedTests = append(failedTests, app.FailedTest{Name: "pkg.TestFail" + strconv.Itoa(i)})
+		}
+		return &app.TestSummary{Total: 14, Passed: 2, Failed: 11, Skipped: 1, FailedTests: failedTests}, nil
+	case "3":
+		return &app.TestSummary{Total: 1, Passed: 1, Flaky: 1, FailedTests: []app.FailedTest{
+			{Name: "TestFlaky", Message: "timeout", Flaky: true}}}, nil
+	}
+	return nil, errors.New("invalid artifact")
+}
+
+func (g *MockGitHubActions) GetArtifactCoverage(ctx context.Context, githubUsername, githubRepo,
+	artifactID string) (*app.Coverage, error) {
+	switch artifactID {
+	case "2":
+		return &app.Coverage{Covered: 75, Total: 100}, nil
+	case "5":
+		return &app.Coverage{Covered: 5, Total: 20}, nil
+	}
+	return nil, errors.New("invalid artifact")
+}
+
 type MockRadiclePatch struct {
-	TotalComments int
-	t             *testing.T
+	TotalComments    int
+	Comments         []string
+	EditedComments   []string
+	RedactedComments []string
+	CodeComments     []string
+	Labels           [][]string
+	t                *testing.T
 }
 
 func (p *MockRadiclePatch) Comment(ctx context.Context, repoID, patchID, revisionID, message string,
-	append bool) error {
+	appendMessage bool) (string, error) {
 	eventUUID := ctx.Value(app.EventUUIDKey).(string)
 	if strings.Contains(eventUUID, "invalid") {
 		p.t.Error("unknown error")
-		return errors.New("unknown error")
+		return "", errors.New("unknown error")
 	}
 	if repoID != "repo_id" {
 		p.t.Error("invalid data")
-		return errors.New("invalid data")
+		return "", errors.New("invalid data")
 	}
 	totalWorkflowsString := eventUUID[len(eventUUID)-1:]
 	totalWorkflows, err := strconv.Atoi(totalWorkflowsString)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	if !strings.Contains(message, "Checking") {
 		if totalWorkflows != strings.Count(message, "[")/2 {
 			p.t.Error("total workflows do not match message")
-			return errors.New("total workflows do not match message")
+			return "", errors.New("total workflows do not match message")
 		}
 	}
+	p.Comments = append(p.Comments, message)
 	p.TotalComments--
 	if p.TotalComments < 0 {
 		p.t.Error("too much comments requested in total")
-		return errors.New("too much comments requested in total")
+		return "", errors.New("too much comments requested in total")
+	}
+	return "comment_id", nil
+}
+
+func (p *MockRadiclePatch) EditComment(ctx context.Context, repoID, patchID, revisionID, commentID,
+	message string) error {
+	if repoID != "repo_id" || patchID != "patch_id" || revisionID != "revision_id" || commentID != "comment_id" {
+		p.t.Error("invalid data")
+		return errors.New("invalid data")
+	}
+	p.EditedComments = append(p.EditedComments, message)
+	return nil
+}
+
+func (p *MockRadiclePatch) RedactComment(ctx context.Context, repoID, patchID, revisionID, commentID string) error {
+	if repoID != "repo_id" || patchID != "patch_id" || revisionID != "revision_id" {
+		p.t.Error("invalid data")
+		return errors.New("invalid data")
+	}
+	p.RedactedComments = append(p.RedactedComments, commentID)
+	return nil
+}
+
+func (p *MockRadiclePatch) CommentCode(ctx context.Context, repoID, patchID, revisionID string,
+	location radicle.CodeLocation, message string) (string, error) {
+	if repoID != "repo_id" || patchID != "patch_id" || revisionID != "revision_id" || location.New == nil {
+		p.t.Error("invalid data")
+		return "", errors.New("invalid data")
+	}
+	p.CodeComments = append(p.CodeComments, fmt.Sprintf("%s %s:%d-%d %s", location.Commit, location.Path,
+		location.New.Range.Start, location.New.Range.End, message))
+	return "code_comment_id", nil
+}
+
+type MockRadicleIssue struct {
+	Comments       []string
+	EditedComments []string
+	Actions        []string
+	// OpenIssues are the issues reported as open, the state of any other issue but "unreachable_issue_id" is closed.
+	OpenIssues []string
+}
+
+func (i *MockRadicleIssue) CommentIssue(ctx context.Context, repoID, issueID, message string,
+	appendMessage bool) (string, error) {
+	if repoID != "repo_id" || issueID != "issue_id" {
+		return "", errors.New("invalid data")
+	}
+	i.Comments = append(i.Comments, message)
+	return "issue_comment_id", nil
+}
+
+func (i *MockRadicleIssue) EditIssueComment(ctx context.Context, repoID, issueID, commentID, message string) error {
+	if repoID != "repo_id" || issueID != "issue_id" || commentID != "issue_comment_id" {
+		return errors.New("invalid data")
 	}
+	i.EditedComments = append(i.EditedComments, message)
+	return nil
+}
+
+func (i *MockRadicleIssue) AddIssueComment(ctx context.Context, repoID, issueID, message string) (string, error) {
+	i.Actions = append(i.Actions, "comment "+issueID)
+	return "failure_comment_id", nil
+}
+
+func (i *MockRadicleIssue) CreateIssue(ctx context.Context, repoID, title, description string) (string, error) {
+	i.Actions = append(i.Actions, "create "+title)
+	return "failure_issue_id", nil
+}
+
+func (i *MockRadicleIssue) SetIssueOpen(ctx context.Context, repoID, issueID string, open bool) error {
+	i.Actions = append(i.Actions, "open "+issueID+" "+strconv.FormatBool(open))
+	return nil
+}
+
+func (i *MockRadicleIssue) IssueOpen(ctx context.Context, repoID, issueID string) (bool, error) {
+	if issueID == "unreachable_issue_id" {
+		return false, errors.New("unreachable")
+	}
+	return slices.Contains(i.OpenIssues, issueID), nil
+}
+
+func (p *MockRadiclePatch) SetLabels(ctx context.Context, repoID, patchID string, labels []string) error {
+	if repoID != "repo_id" || patchID != "patch_id" {
+		p.t.Error("invalid data")
+		return errors.New("invalid data")
+	}
+	p.Labels = append(p.Labels, labels)
 	return nil
 }
 
@@ -433,8 +624,190 @@ func TestGitHubActions_Serve(t *testing.T) {
 	}
 }
 
+func TestGitHubActions_ServeInterrupted(t *testing.T) {
+	radiclePatch := MockRadiclePatch{TotalComments: 2, t: t}
+	jobStore := MockJobStore{jobs: map[string]jobs.Job{}}
+	mockMetrics := MockMetrics{}
+	gas := &GitHubActionsServer{
+		App: &App{
+			Config: AppConfig{
+				WorkflowsStartLagSecs:   60,
+				WorkflowsPollTimoutSecs: 60,
+				ShutdownGraceSecs:       1,
+			},
+			Logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+		},
+		Broker:        &MockBroker{},
+		GitHubActions: &MockGitHubActions{},
+		Radicle:       &radiclePatch,
+		JobStore:      &jobStore,
+		Metrics:       &mockMetrics,
+	}
+	ctx, cancel := context.WithCancel(context.WithValue(context.WithValue(context.Background(), app.EventUUIDKey,
+		"event-uuid-patch-valid-0"), app.RepoClonePathKey, "event-uuid-patch-valid-0"))
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := gas.Serve(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Serve() error = %v, want %v", err, context.Canceled)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("Serve() did not stop waiting on cancellation")
+	}
+	if len(radiclePatch.Comments) != 2 || !strings.Contains(radiclePatch.Comments[1], "interrupted") {
+		t.Errorf("Serve() got comments %v, want an interrupted comment", radiclePatch.Comments)
+	}
+	job := jobStore.jobs["event-uuid-patch-valid-0"]
+	if job.Phase != jobs.JobPhaseAborted || job.Result != app.BrokerResultFailure {
+		t.Errorf("Serve() got job phase %s result %s, want %s %s", job.Phase, job.Result, jobs.JobPhaseAborted,
+			app.BrokerResultFailure)
+	}
+	if !reflect.DeepEqual(mockMetrics.Jobs, []string{"patch.created aborted"}) {
+		t.Errorf("Serve() observed jobs %v, want [patch.created aborted]", mockMetrics.Jobs)
+	}
+}
+
+func TestGitHubActions_ServeJobDeadline(t *testing.T) {
+	radiclePatch := MockRadiclePatch{TotalComments: 2, t: t}
+	gas := &GitHubActionsServer{
+		App: &App{
+			Config: AppConfig{
+				WorkflowsStartLagSecs:   60,
+				WorkflowsPollTimoutSecs: 60,
+				ShutdownGraceSecs:       1,
+				JobTimeoutSecs:          1,
+			},
+			Logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+		},
+		Broker:        &MockBroker{},
+		GitHubActions: &MockGitHubActions{},
+		Radicle:       &radiclePatch,
+	}
+	ctx := context.WithValue(context.WithValue(context.Background(), app.EventUUIDKey,
+		"event-uuid-patch-valid-0"), app.RepoClonePathKey, "event-uuid-patch-valid-0")
+
+	err := gas.Serve(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Serve() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if len(radiclePatch.Comments) != 2 || !strings.Contains(radiclePatch.Comments[1], "deadline") {
+		t.Errorf("Serve() got comments %v, want a deadline exceeded comment", radiclePatch.Comments)
+	}
+}
+
+func TestGitHubActions_UpdateResponseResults(t *testing.T) {
+	workflowsResult := []app.WorkflowResult{
+		{WorkflowID: "1", WorkflowName: "BuildTest", Status: githubops.WorkflowStatusCompleted,
+			Result: githubops.WorkflowResultSuccess},
+		{WorkflowID: "2", WorkflowName: "UnitTests", Status: githubops.WorkflowStatusInProgress},
+	}
+	cases := []struct {
+		name             string
+		timedOut         bool
+		timeoutNeutral   bool
+		expectedResult   string
+		expectedWorkflow string
+	}{
+		{
+			name:             "UpdateResponseResults fails when workflows did not complete",
+			expectedResult:   app.BrokerResultFailure,
+			expectedWorkflow: string(githubops.WorkflowStatusInProgress),
+		},
+		{
+			name:             "UpdateResponseResults reports timed out workflows as failure",
+			timedOut:         true,
+			expectedResult:   app.BrokerResultFailure,
+			expectedWorkflow: app.WorkflowResultStillRunning,
+		},
+		{
+			name:             "UpdateResponseResults reports timed out workflows as neutral",
+			timedOut:         true,
+			timeoutNeutral:   true,
+			expectedResult:   app.BrokerResultSuccess,
+			expectedWorkflow: app.WorkflowResultStillRunning,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gas := GitHubActionsServer{App: &App{Config: AppConfig{WorkflowsTimeoutNeutral: tc.timeoutNeutral}}}
+			resultResponse := broker.ResponseMessage{
+				Response: app.BrokerResponseFinished,
+				Result:   app.BrokerResultSuccess,
+				TimedOut: tc.timedOut,
+			}
+			gas.updateResponseResults(&resultResponse, workflowsResult, nil)
+			if resultResponse.Result != tc.expectedResult {
+				t.Errorf("expected result %s, but got %s", tc.expectedResult, resultResponse.Result)
+			}
+			if resultResponse.ResultDetails[1].WorkflowResult != tc.expectedWorkflow {
+				t.Errorf("expected workflow result %s, but got %s", tc.expectedWorkflow,
+					resultResponse.ResultDetails[1].WorkflowResult)
+			}
+		})
+	}
+}
+
+func TestGitHubActions_ConclusionOutcomes(t *testing.T) {
+	cancelledNeutral := maps.Clone(githubops.DefaultConclusionOutcomes)
+	cancelledNeutral[githubops.WorkflowResultCancelled] = githubops.ConclusionOutcomeNeutral
+	cancelledNeutral[githubops.WorkflowResultSkipped] = githubops.ConclusionOutcomeFail
+	cases := []struct {
+		name           string
+		outcomes       map[githubops.WorkflowConclusion]githubops.ConclusionOutcome
+		conclusions    []githubops.WorkflowConclusion
+		expectedResult string
+	}{
+		{
+			name:           "Skipped and neutral conclusions pass by default",
+			conclusions:    []githubops.WorkflowConclusion{githubops.WorkflowResultSkipped, githubops.WorkflowResultNeutral},
+			expectedResult: app.BrokerResultSuccess,
+		},
+		{
+			name:           "Cancelled conclusion fails by default",
+			conclusions:    []githubops.WorkflowConclusion{githubops.WorkflowResultSuccess, githubops.WorkflowResultCancelled},
+			expectedResult: app.BrokerResultFailure,
+		},
+		{
+			name:           "Unknown conclusion fails",
+			conclusions:    []githubops.WorkflowConclusion{"unknown"},
+			expectedResult: app.BrokerResultFailure,
+		},
+		{
+			name:           "Cancelled conclusion is configured as neutral",
+			outcomes:       cancelledNeutral,
+			conclusions:    []githubops.WorkflowConclusion{githubops.WorkflowResultCancelled},
+			expectedResult: app.BrokerResultSuccess,
+		},
+		{
+			name:           "Skipped conclusion is configured as fail",
+			outcomes:       cancelledNeutral,
+			conclusions:    []githubops.WorkflowConclusion{githubops.WorkflowResultSkipped},
+			expectedResult: app.BrokerResultFailure,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gas := GitHubActionsServer{App: &App{Config: AppConfig{ConclusionOutcomes: tc.outcomes}}}
+			var workflowsResult []app.WorkflowResult
+			for i, conclusion := range tc.conclusions {
+				workflowsResult = append(workflowsResult, app.WorkflowResult{WorkflowID: strconv.Itoa(i),
+					Status: githubops.WorkflowStatusCompleted, Result: conclusion})
+			}
+			resultResponse := broker.ResponseMessage{
+				Response: app.BrokerResponseFinished,
+				Result:   app.BrokerResultSuccess,
+			}
+			gas.updateResponseResults(&resultResponse, workflowsResult, nil)
+			if resultResponse.Result != tc.expectedResult {
+				t.Errorf("expected result %s, but got %s", tc.expectedResult, resultResponse.Result)
+			}
+		})
+	}
+}
+
 func TestGitHubActions_PreparePatchCommentMessage(t *testing.T) {
-	gas := GitHubActionsServer{}
+	gas := GitHubActionsServer{App: &App{Config: AppConfig{WorkflowsPollTimoutSecs: 1800}}}
 
 	githubActionsSettings := app.GitHubActionsSettings{
 		GitHubUsername: "testUser",
@@ -449,23 +822,42 @@ func TestGitHubActions_PreparePatchCommentMessage(t *testing.T) {
 		{
 			name: "PreparePatchCommentMessage is successful using only successful results",
 			response: broker.ResponseMessage{
-				Result: githubops.WorkflowResultSuccess,
+				Result: string(githubops.WorkflowResultSuccess),
 				ResultDetails: []broker.WorkflowDetails{
-					{WorkflowID: "1", WorkflowName: "BuildTest", WorkflowResult: githubops.WorkflowResultSuccess},
-					{WorkflowID: "2", WorkflowName: "UnitTests", WorkflowResult: githubops.WorkflowResultFailure},
+					{WorkflowID: "1", WorkflowName: "BuildTest", WorkflowResult: string(githubops.WorkflowResultSuccess)},
+					{WorkflowID: "2", WorkflowName: "UnitTests", WorkflowResult: string(githubops.WorkflowResultFailure)},
 				},
 			},
 			expected: "GitHub Actions Result: success ✅  \n Workflows:  \n " +
 				"- BuildTest ([#1](https://github.com/testUser/testRepo/actions/runs/1)) [✅](# \"success\")  \n " +
 				"- UnitTests ([#2](https://github.com/testUser/testRepo/actions/runs/2)) [❌](# \"failure\")",
 		},
+		{
+			name: "PreparePatchCommentMessage names the commits of the workflows when several commits are checked",
+			response: broker.ResponseMessage{
+				Result: app.BrokerResultFailure,
+				ResultDetails: []broker.WorkflowDetails{
+					{WorkflowID: "1", WorkflowName: "BuildTest",
+						WorkflowResult: string(githubops.WorkflowResultSuccess), WorkflowCommit: "0123456789abcdef"},
+					{WorkflowID: "2", WorkflowName: "BuildTest",
+						WorkflowResult: string(githubops.WorkflowResultFailure), WorkflowCommit: "fedcba9876543210"},
+				},
+				CommitResults: map[string]string{"0123456789abcdef": app.BrokerResultSuccess,
+					"fedcba9876543210": app.BrokerResultFailure, "abcdefabcdefabcd": app.CommitResultNoWorkflows},
+			},
+			expected: "GitHub Actions Result: failure ❌  \n Workflows:  \n " +
+				"- BuildTest ([#1](https://github.com/testUser/testRepo/actions/runs/1)) [✅](# \"success\") " +
+				"on `0123456`  \n " +
+				"- BuildTest ([#2](https://github.com/testUser/testRepo/actions/runs/2)) [❌](# \"failure\") " +
+				"on `fedcba9`  \n - `abcdefa` has no workflows",
+		},
 		{
 			name: "PreparePatchCommentMessage is successful using only failed results",
 			response: broker.ResponseMessage{
-				Result: githubops.WorkflowResultFailure,
+				Result: string(githubops.WorkflowResultFailure),
 				ResultDetails: []broker.WorkflowDetails{
-					{WorkflowID: "1", WorkflowName: "BuildTest", WorkflowResult: githubops.WorkflowResultSuccess},
-					{WorkflowID: "2", WorkflowName: "UnitTests", WorkflowResult: githubops.WorkflowResultFailure},
+					{WorkflowID: "1", WorkflowName: "BuildTest", WorkflowResult: string(githubops.WorkflowResultSuccess)},
+					{WorkflowID: "2", WorkflowName: "UnitTests", WorkflowResult: string(githubops.WorkflowResultFailure)},
 				},
 			},
 			expected: "GitHub Actions Result: failure ❌  \n Workflows:  \n " +
@@ -475,10 +867,10 @@ func TestGitHubActions_PreparePatchCommentMessage(t *testing.T) {
 		{
 			name: "PreparePatchCommentMessage is successful using mixed results",
 			response: broker.ResponseMessage{
-				Result: githubops.WorkflowResultFailure,
+				Result: string(githubops.WorkflowResultFailure),
 				ResultDetails: []broker.WorkflowDetails{
-					{WorkflowID: "1", WorkflowName: "BuildTest", WorkflowResult: githubops.WorkflowResultSuccess},
-					{WorkflowID: "2", WorkflowName: "UnitTests", WorkflowResult: githubops.WorkflowResultFailure},
+					{WorkflowID: "1", WorkflowName: "BuildTest", WorkflowResult: string(githubops.WorkflowResultSuccess)},
+					{WorkflowID: "2", WorkflowName: "UnitTests", WorkflowResult: string(githubops.WorkflowResultFailure)},
 					{WorkflowID: "3", WorkflowName: "IntegrationTests", WorkflowResult: "otherResult"},
 				},
 			},
@@ -487,10 +879,154 @@ func TestGitHubActions_PreparePatchCommentMessage(t *testing.T) {
 				"UnitTests ([#2](https://github.com/testUser/testRepo/actions/runs/2)) [❌](# \"failure\")  \n - " +
 				"IntegrationTests ([#3](https://github.com/testUser/testRepo/actions/runs/3)) [⚠️️](# \"otherResult\")",
 		},
+		{
+			name: "PreparePatchCommentMessage is successful using all GitHub conclusions and statuses",
+			response: broker.ResponseMessage{
+				Response: app.BrokerResponseInProgress,
+				ResultDetails: []broker.WorkflowDetails{
+					{WorkflowID: "1", WorkflowName: "Cancelled", WorkflowResult: string(githubops.WorkflowResultCancelled)},
+					{WorkflowID: "2", WorkflowName: "Skipped", WorkflowResult: string(githubops.WorkflowResultSkipped)},
+					{WorkflowID: "3", WorkflowName: "Neutral", WorkflowResult: string(githubops.WorkflowResultNeutral)},
+					{WorkflowID: "4", WorkflowName: "TimedOut", WorkflowResult: string(githubops.WorkflowResultTimedOut)},
+					{WorkflowID: "5", WorkflowName: "ActionRequired",
+						WorkflowResult: string(githubops.WorkflowResultActionRequired)},
+					{WorkflowID: "6", WorkflowName: "Stale", WorkflowResult: string(githubops.WorkflowResultStale)},
+					{WorkflowID: "7", WorkflowName: "Queued", WorkflowResult: string(githubops.WorkflowStatusQueued)},
+					{WorkflowID: "8", WorkflowName: "Waiting", WorkflowResult: string(githubops.WorkflowStatusWaiting)},
+				},
+			},
+			expected: "GitHub Actions Status: in progress ⏳  \n Workflows:  \n " +
+				"- Cancelled ([#1](https://github.com/testUser/testRepo/actions/runs/1)) [🚫](# \"cancelled\")  \n " +
+				"- Skipped ([#2](https://github.com/testUser/testRepo/actions/runs/2)) [⏭️](# \"skipped\")  \n " +
+				"- Neutral ([#3](https://github.com/testUser/testRepo/actions/runs/3)) [➖](# \"neutral\")  \n " +
+				"- TimedOut ([#4](https://github.com/testUser/testRepo/actions/runs/4)) [⌛](# \"timed out\")  \n " +
+				"- ActionRequired ([#5](https://github.com/testUser/testRepo/actions/runs/5)) [✋](# \"action required\")  \n " +
+				"- Stale ([#6](https://github.com/testUser/testRepo/actions/runs/6)) [💤](# \"stale\")  \n " +
+				"- Queued ([#7](https://github.com/testUser/testRepo/actions/runs/7)) [🕒](# \"queued\")  \n " +
+				"- Waiting ([#8](https://github.com/testUser/testRepo/actions/runs/8)) [⏸️](# \"waiting\")",
+		},
+		{
+			name: "PreparePatchCommentMessage is successful with previous attempts",
+			response: broker.ResponseMessage{
+				Result: app.BrokerResultSuccess,
+				ResultDetails: []broker.WorkflowDetails{
+					{WorkflowID: "3", WorkflowName: "BuildTest", WorkflowResult: string(githubops.WorkflowResultSuccess),
+						WorkflowAttempt: 2, PreviousAttempts: []broker.WorkflowAttempt{
+							{RunID: "3", RunAttempt: 1, Result: string(githubops.WorkflowResultFailure),
+								Url: "https://github.com/testUser/testRepo/actions/runs/3/attempts/1"},
+							{RunID: "1", RunAttempt: 1, Result: string(githubops.WorkflowResultCancelled),
+								Url: "https://github.com/testUser/testRepo/actions/runs/1/attempts/1"},
+						}},
+				},
+			},
+			expected: "GitHub Actions Result: success ✅  \n Workflows:  \n " +
+				"- BuildTest ([#3](https://github.com/testUser/testRepo/actions/runs/3)) [✅](# \"success\") " +
+				"(attempt 2)  \n\t Previous attempts:  \n\t\t " +
+				"- [#3 attempt 1](https://github.com/testUser/testRepo/actions/runs/3/attempts/1) [❌](# \"failure\")" +
+				"  \n\t\t " +
+				"- [#1 attempt 1](https://github.com/testUser/testRepo/actions/runs/1/attempts/1) [🚫](# \"cancelled\")",
+		},
+		{
+			name: "PreparePatchCommentMessage is successful with run details",
+			response: broker.ResponseMessage{
+				Result: app.BrokerResultSuccess,
+				ResultDetails: []broker.WorkflowDetails{
+					{WorkflowID: "12", WorkflowName: "BuildTest", WorkflowResult: string(githubops.WorkflowResultSuccess),
+						WorkflowRunNumber: 7, WorkflowAttempt: 2, WorkflowEvent: "push", WorkflowHeadBranch: "main",
+						WorkflowURL: "https://github.com/testUser/testRepo/actions/runs/12/attempts/2"},
+					{WorkflowID: "13", WorkflowName: "Lint", WorkflowResult: string(githubops.WorkflowResultSuccess),
+						WorkflowRunNumber: 3, WorkflowAttempt: 1, WorkflowHeadBranch: "main"},
+				},
+			},
+			expected: "GitHub Actions Result: success ✅  \n Workflows:  \n " +
+				"- BuildTest ([#12](https://github.com/testUser/testRepo/actions/runs/12/attempts/2)) " +
+				"[✅](# \"success\") (run 7, attempt 2, push on main)  \n " +
+				"- Lint ([#13](https://github.com/testUser/testRepo/actions/runs/13)) [✅](# \"success\") " +
+				"(run 3, on main)",
+		},
+		{
+			name: "PreparePatchCommentMessage is successful using timed out results",
+			response: broker.ResponseMessage{
+				Result:   string(githubops.WorkflowResultFailure),
+				TimedOut: true,
+				ResultDetails: []broker.WorkflowDetails{
+					{WorkflowID: "1", WorkflowName: "BuildTest", WorkflowResult: string(githubops.WorkflowResultSuccess)},
+					{WorkflowID: "2", WorkflowName: "UnitTests", WorkflowResult: app.WorkflowResultStillRunning},
+				},
+			},
+			expected: "GitHub Actions Result: timed out ⏱️  \n *Workflows still running on GitHub after 30m.*  \n " +
+				"Workflows:  \n " +
+				"- BuildTest ([#1](https://github.com/testUser/testRepo/actions/runs/1)) [✅](# \"success\")  \n " +
+				"- UnitTests ([#2](https://github.com/testUser/testRepo/actions/runs/2)) [⏱️](# \"still running\")",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := gas.preparePatchCommentResultMessage(tc.response, githubActionsSettings)
+			if result != tc.expected {
+				t.Fatalf("expected %s, but got %s", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestGitHubActions_PreparePatchCommentMessageSummary(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	startedAt := createdAt.Add(30 * time.Second)
+	updatedAt := startedAt.Add(2 * time.Minute)
+	githubActionsSettings := app.GitHubActionsSettings{
+		GitHubUsername: "testUser",
+		GitHubRepo:     "testRepo",
+	}
+	response := broker.ResponseMessage{
+		Response: app.BrokerResponseFinished,
+		Result:   app.BrokerResultFailure,
+		ResultDetails: []broker.WorkflowDetails{
+			{WorkflowID: "1", WorkflowName: "BuildTest", WorkflowResult: string(githubops.WorkflowResultSuccess),
+				WorkflowRunNumber: 7, WorkflowAttempt: 1, WorkflowCreatedAt: &createdAt,
+				WorkflowStartedAt: &startedAt, WorkflowUpdatedAt: &updatedAt, WorkflowBillableMS: 61000},
+			{WorkflowID: "2", WorkflowName: "UnitTests", WorkflowResult: string(githubops.WorkflowResultFailure),
+				WorkflowCreatedAt: &createdAt, WorkflowUpdatedAt: &updatedAt, WorkflowBillableMS: 30000},
+			{WorkflowID: "3", WorkflowName: "Lint", WorkflowResult: string(githubops.WorkflowResultSuccess),
+				WorkflowRunNumber: 8, WorkflowAttempt: 2, WorkflowCreatedAt: &createdAt,
+				WorkflowStartedAt: &startedAt, WorkflowUpdatedAt: &updatedAt},
+		},
+	}
+	workflows := "GitHub Actions Result: failure ❌  \n Workflows:  \n " +
+		"- BuildTest ([#1](https://github.com/testUser/testRepo/actions/runs/1)) [✅](# \"success\") (run 7)  \n " +
+		"- UnitTests ([#2](https://github.com/testUser/testRepo/actions/runs/2)) [❌](# \"failure\")  \n " +
+		"- Lint ([#3](https://github.com/testUser/testRepo/actions/runs/3)) [✅](# \"success\") " +
+		"(run 8, attempt 2)\n\n" +
+		"| Workflow | Conclusion | Duration | Queued | Run | Attempt |\n" +
+		"| --- | --- | --- | --- | --- | --- |\n" +
+		"| BuildTest | ✅ success | 2m | 30s | 7 | 1 |\n" +
+		"| UnitTests | ❌ failure | 2m30s | - | - | - |\n" +
+		"| Lint | ✅ success | 2m | - | 8 | 2 |"
+
+	cases := []struct {
+		name      string
+		createdAt time.Time
+		response  broker.ResponseMessage
+		expected  string
+	}{
+		{
+			name:     "PreparePatchCommentMessage adds the billable minutes of a finished response",
+			response: response,
+			expected: workflows + "\n\nTotal: - wall time, 2 billable minutes",
+		},
+		{
+			name:      "PreparePatchCommentMessage adds the wall time of the job of a finished response",
+			createdAt: time.Now().Add(-5 * time.Minute),
+			response:  response,
+			expected:  workflows + "\n\nTotal: 5m wall time, 2 billable minutes",
+		},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
+			gas := GitHubActionsServer{App: &App{Config: AppConfig{WorkflowsPollTimoutSecs: 1800}},
+				job: jobs.Job{CreatedAt: tc.createdAt}}
 			result := gas.preparePatchCommentResultMessage(tc.response, githubActionsSettings)
 			if result != tc.expected {
 				t.Fatalf("expected %s, but got %s", tc.expected, result)
@@ -498,3 +1034,1157 @@ func TestGitHubActions_PreparePatchCommentMessage(t *testing.T) {
 		})
 	}
 }
+
+type MockJobStore struct {
+	jobs map[string]jobs.Job
+}
+
+func (s *MockJobStore) Save(ctx context.Context, job jobs.Job) error {
+	s.jobs[job.ID] = job
+	return nil
+}
+
+func (s *MockJobStore) Get(ctx context.Context, id string) (*jobs.Job, error) {
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, jobs.ErrJobNotFound
+	}
+	return &job, nil
+}
+
+func (s *MockJobStore) List(ctx context.Context) ([]jobs.Job, error) {
+	var result []jobs.Job
+	for _, job := range s.jobs {
+		result = append(result, job)
+	}
+	return result, nil
+}
+
+func (s *MockJobStore) Delete(ctx context.Context, id string) error {
+	delete(s.jobs, id)
+	return nil
+}
+
+func TestGitHubActions_Reconcile(t *testing.T) {
+	old := time.Now().Add(-time.Hour)
+	expired := time.Now().Add(-31 * 24 * time.Hour)
+	coverage := 80.0
+	jobStore := MockJobStore{jobs: map[string]jobs.Job{
+		"completed": {ID: "completed", Repo: "repo_id", Commit: "2", PatchID: "patch_id",
+			RevisionID: "revision_id", CommentID: "comment_id", GitHubUsername: "repo_user",
+			GitHubRepo: "repo_name", Phase: jobs.JobPhaseWaiting, CreatedAt: old},
+		"unreachable": {ID: "unreachable", Repo: "repo_id", Commit: "1", PatchID: "patch_id",
+			RevisionID: "revision_id", CommentID: "comment_id", GitHubUsername: "other_user",
+			GitHubRepo: "repo_name", Phase: jobs.JobPhaseWaiting, CreatedAt: old},
+		"no-setup": {ID: "no-setup", Repo: "repo_id", Commit: "1", Phase: jobs.JobPhaseTriggered,
+			CreatedAt: old},
+		"recent": {ID: "recent", Repo: "repo_id", Commit: "1", Phase: jobs.JobPhaseWaiting,
+			CreatedAt: time.Now()},
+		"running": {ID: "running", Repo: "repo_id", Commit: "1", Phase: jobs.JobPhaseWaiting,
+			CreatedAt: time.Now().Add(-3 * time.Minute)},
+		"finished": {ID: "finished", Repo: "repo_id", Commit: "1", Phase: jobs.JobPhaseFinished,
+			Result: app.BrokerResultSuccess, CreatedAt: old, UpdatedAt: old},
+		"expired-patch": {ID: "expired-patch", Repo: "repo_id", Commit: "1", PatchID: "patch_id",
+			Phase: jobs.JobPhaseFinished, CreatedAt: expired, UpdatedAt: expired},
+		"expired-push": {ID: "expired-push", Repo: "repo_id", Commit: "1", Branch: "main",
+			Phase: jobs.JobPhaseAborted, CreatedAt: expired.Add(-3 * time.Hour), UpdatedAt: expired},
+		"issue-push": {ID: "issue-push", Repo: "repo_id", Commit: "1", Branch: "main",
+			Phase: jobs.JobPhaseFinished, FailureIssueID: "issue_id", CreatedAt: expired.Add(-2 * time.Hour),
+			UpdatedAt: expired},
+		"coverage-push": {ID: "coverage-push", Repo: "repo_id", Commit: "1", Branch: "main",
+			Phase: jobs.JobPhaseFinished, Coverage: &coverage, CreatedAt: expired.Add(-time.Hour), UpdatedAt: expired},
+		"latest-push": {ID: "latest-push", Repo: "repo_id", Commit: "2", Branch: "main",
+			Phase: jobs.JobPhaseFinished, CreatedAt: expired, UpdatedAt: expired},
+		"tracked-push": {ID: "tracked-push", Repo: "repo_id", Commit: "1", Branch: "release", IssueID: "issue_id",
+			CommentID: "issue_comment_id", Phase: jobs.JobPhaseWaiting, CreatedAt: old},
+	}}
+	radiclePatch := MockRadiclePatch{t: t}
+	radicleIssue := MockRadicleIssue{}
+	progressLog := MockProgressLog{}
+	gas := &GitHubActionsServer{
+		App: &App{
+			Config: AppConfig{WorkflowsPollTimoutSecs: 60, JobsRetentionDays: 30},
+			Logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+		},
+		GitHubActions: &MockGitHubActions{},
+		Radicle:       &radiclePatch,
+		Issues:        &radicleIssue,
+		JobStore:      &jobStore,
+		ProgressLog:   &progressLog,
+	}
+	ctx := context.WithValue(context.Background(), app.EventUUIDKey, "event-uuid-reconcile")
+	if err := gas.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	expected := map[string]struct {
+		phase  string
+		result string
+	}{
+		"completed":     {jobs.JobPhaseFinished, app.BrokerResultFailure},
+		"unreachable":   {jobs.JobPhaseAborted, app.BrokerResultFailure},
+		"no-setup":      {jobs.JobPhaseAborted, app.BrokerResultFailure},
+		"recent":        {jobs.JobPhaseWaiting, ""},
+		"running":       {jobs.JobPhaseWaiting, ""},
+		"finished":      {jobs.JobPhaseFinished, app.BrokerResultSuccess},
+		"latest-push":   {jobs.JobPhaseFinished, ""},
+		"issue-push":    {jobs.JobPhaseFinished, ""},
+		"coverage-push": {jobs.JobPhaseFinished, ""},
+		"tracked-push":  {jobs.JobPhaseAborted, app.BrokerResultFailure},
+	}
+	for id, want := range expected {
+		got := jobStore.jobs[id]
+		if got.Phase != want.phase || got.Result != want.result {
+			t.Errorf("Reconcile() job %s got phase %s result %s, want phase %s result %s", id, got.Phase,
+				got.Result, want.phase, want.result)
+		}
+	}
+	for _, id := range []string{"expired-patch", "expired-push"} {
+		if _, found := jobStore.jobs[id]; found {
+			t.Errorf("Reconcile() expected job %s to be pruned", id)
+		}
+	}
+	slices.Sort(progressLog.Deleted)
+	if !slices.Equal(progressLog.Deleted, []string{"expired-patch", "expired-push"}) {
+		t.Errorf("Reconcile() deleted the progress of %v, want the pruned jobs", progressLog.Deleted)
+	}
+	if len(radiclePatch.EditedComments) != 2 {
+		t.Fatalf("Reconcile() expected 2 edited comments, got %d", len(radiclePatch.EditedComments))
+	}
+	var results, aborted int
+	for _, comment := range radiclePatch.EditedComments {
+		if strings.HasPrefix(comment, "GitHub Actions Result: failure") {
+			results++
+		}
+		if strings.HasPrefix(comment, "GitHub Actions Result: adapter aborted") {
+			aborted++
+		}
+	}
+	if results != 1 || aborted != 1 {
+		t.Errorf("Reconcile() got %d result and %d aborted comments, want 1 and 1", results, aborted)
+	}
+	if len(radicleIssue.EditedComments) != 1 || !strings.HasPrefix(radicleIssue.EditedComments[0],
+		"Push of `1` to `release`  \n GitHub Actions Result: adapter aborted") {
+		t.Errorf("Reconcile() got issue comments %q, want the aborted push to release", radicleIssue.EditedComments)
+	}
+}
+
+func TestReportProgress(t *testing.T) {
+	polls := [][]app.WorkflowResult{
+		{
+			{WorkflowID: "1", WorkflowName: "build", Status: githubops.WorkflowStatusQueued},
+			{WorkflowID: "2", WorkflowName: "test", Status: githubops.WorkflowStatusInProgress},
+		},
+		{
+			{WorkflowID: "1", WorkflowName: "build", Status: githubops.WorkflowStatusQueued},
+			{WorkflowID: "2", WorkflowName: "test", Status: githubops.WorkflowStatusInProgress},
+		},
+		{
+			{WorkflowID: "1", WorkflowName: "build", Status: githubops.WorkflowStatusCompleted,
+				Result: githubops.WorkflowResultSuccess},
+			{WorkflowID: "2", WorkflowName: "test", Status: githubops.WorkflowStatusInProgress},
+		},
+	}
+	expected := [][]broker.WorkflowProgress{
+		{
+			{WorkflowID: "1", WorkflowName: "build", Status: string(githubops.WorkflowStatusQueued)},
+			{WorkflowID: "2", WorkflowName: "test", Status: string(githubops.WorkflowStatusInProgress)},
+		},
+		{
+			{WorkflowID: "1", WorkflowName: "build", Status: string(githubops.WorkflowResultSuccess),
+				PreviousStatus: string(githubops.WorkflowStatusQueued)},
+		},
+	}
+	cases := []struct {
+		name              string
+		progressSupported bool
+	}{
+		{name: "reportProgress sends progress messages to the broker", progressSupported: true},
+		{name: "reportProgress writes progress messages to the progress log", progressSupported: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockBroker := &MockBroker{ProgressSupported: tc.progressSupported}
+			progressLog := &MockProgressLog{}
+			jobStore := &MockJobStore{jobs: map[string]jobs.Job{}}
+			mockMetrics := &MockMetrics{}
+			gas := &GitHubActionsServer{
+				App:         &App{Logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{}))},
+				Broker:      mockBroker,
+				ProgressLog: progressLog,
+				JobStore:    jobStore,
+				Metrics:     mockMetrics,
+				job:         jobs.Job{ID: "event-uuid"},
+			}
+			workflowsStatus := map[string]string{}
+			for _, poll := range polls {
+				gas.reportProgress(context.Background(), workflowsStatus, poll)
+			}
+			got, other := mockBroker.Progress, progressLog.Progress
+			if !tc.progressSupported {
+				got, other = progressLog.Progress, mockBroker.Progress
+			}
+			if len(other) != 0 {
+				t.Fatalf("expected no progress messages on the other destination, got %+v", other)
+			}
+			if len(got) != len(expected) {
+				t.Fatalf("expected %d progress messages, got %+v", len(expected), got)
+			}
+			for i, progressMessage := range got {
+				if progressMessage.Response != app.BrokerResponseProgress || progressMessage.RunID.ID != "event-uuid" {
+					t.Errorf("unexpected progress message %+v", progressMessage)
+				}
+				if !reflect.DeepEqual(progressMessage.Workflows, expected[i]) {
+					t.Errorf("expected workflows %+v, got %+v", expected[i], progressMessage.Workflows)
+				}
+			}
+			expectedJobWorkflows := []jobs.JobWorkflow{
+				{ID: "1", Name: "build", Status: string(githubops.WorkflowResultSuccess)},
+				{ID: "2", Name: "test", Status: string(githubops.WorkflowStatusInProgress)},
+			}
+			if !reflect.DeepEqual(jobStore.jobs["event-uuid"].Workflows, expectedJobWorkflows) {
+				t.Errorf("expected job workflows %+v, got %+v", expectedJobWorkflows,
+					jobStore.jobs["event-uuid"].Workflows)
+			}
+			if mockMetrics.TimesToFirstRun != 1 {
+				t.Errorf("expected the time to first run observed once, got %d", mockMetrics.TimesToFirstRun)
+			}
+		})
+	}
+}
+
+func TestInfoURL(t *testing.T) {
+	gitHubActionsSettings := &app.GitHubActionsSettings{GitHubUsername: "testUser", GitHubRepo: "testRepo"}
+	cases := []struct {
+		name                  string
+		statusPageURL         string
+		gitHubActionsSettings *app.GitHubActionsSettings
+		expected              string
+	}{
+		{
+			name:                  "infoURL links to the GitHub checks of the commit",
+			gitHubActionsSettings: gitHubActionsSettings,
+			expected:              "https://github.com/testUser/testRepo/commit/commit_hash/checks",
+		},
+		{
+			name:                  "infoURL prefers the status page",
+			statusPageURL:         "http://127.0.0.1:8090/",
+			gitHubActionsSettings: gitHubActionsSettings,
+			expected:              "http://127.0.0.1:8090/jobs/event-uuid",
+		},
+		{
+			name:     "infoURL is empty without GitHub Actions settings",
+			expected: "",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gas := GitHubActionsServer{App: &App{Config: AppConfig{StatusPageURL: tc.statusPageURL}}}
+			result := gas.infoURL("event-uuid", "commit_hash", tc.gitHubActionsSettings)
+			if result != tc.expected {
+				t.Fatalf("expected %s, but got %s", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestGitHubActions_ServeInfoURL(t *testing.T) {
+	cases := []struct {
+		name              string
+		statusPageURL     string
+		expectedTriggered string
+		expectedFinished  string
+	}{
+		{
+			name:              "Serve links the finished response only to the GitHub checks",
+			expectedTriggered: "",
+			expectedFinished:  "https://github.com/repo_user/repo_name/commit/0/checks",
+		},
+		{
+			name:              "Serve links both responses to the status page",
+			statusPageURL:     "http://127.0.0.1:8090",
+			expectedTriggered: "http://127.0.0.1:8090/jobs/event-uuid-push-0",
+			expectedFinished:  "http://127.0.0.1:8090/jobs/event-uuid-push-0",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockBroker := MockBroker{}
+			gas := &GitHubActionsServer{
+				App: &App{
+					Config: AppConfig{StatusPageURL: tc.statusPageURL},
+					Logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+				},
+				Broker:        &mockBroker,
+				GitHubActions: &MockGitHubActions{},
+				Radicle:       &MockRadiclePatch{t: t},
+			}
+			ctx := context.WithValue(context.WithValue(context.Background(), app.EventUUIDKey, "event-uuid-push-0"),
+				app.RepoClonePathKey, "event-uuid-push-0")
+			if err := gas.Serve(ctx); err != nil {
+				t.Fatalf("Serve() error = %v", err)
+			}
+			if len(mockBroker.Responses) != 2 {
+				t.Fatalf("Serve() got responses %+v, want triggered and finished", mockBroker.Responses)
+			}
+			triggered, finished := mockBroker.Responses[0], mockBroker.Responses[1]
+			if triggered.Response != app.BrokerResponseTriggered || triggered.InfoURL != tc.expectedTriggered {
+				t.Errorf("Serve() got triggered response %+v, want info URL %q", triggered, tc.expectedTriggered)
+			}
+			if finished.Response != app.BrokerResponseFinished || finished.InfoURL != tc.expectedFinished {
+				t.Errorf("Serve() got finished response %+v, want info URL %q", finished, tc.expectedFinished)
+			}
+		})
+	}
+}
+
+func TestGitHubActions_UpdateResponseCommitResults(t *testing.T) {
+	gas := GitHubActionsServer{App: &App{}}
+	resultResponse := broker.ResponseMessage{
+		Response: app.BrokerResponseFinished,
+		Result:   app.BrokerResultSuccess,
+	}
+	gas.updateResponseResults(&resultResponse, []app.WorkflowResult{
+		{WorkflowID: "1", WorkflowName: "BuildTest", Status: githubops.WorkflowStatusCompleted,
+			Result: githubops.WorkflowResultSuccess, Commit: "head"},
+		{WorkflowID: "2", WorkflowName: "BuildTest", Status: githubops.WorkflowStatusCompleted,
+			Result: githubops.WorkflowResultFailure, Commit: "parent"},
+		{WorkflowID: "3", WorkflowName: "UnitTests", Status: githubops.WorkflowStatusCompleted,
+			Result: githubops.WorkflowResultSuccess, Commit: "parent"},
+	}, []string{"head", "parent", "grandparent"})
+	if resultResponse.Result != app.BrokerResultFailure {
+		t.Errorf("expected result %s, but got %s", app.BrokerResultFailure, resultResponse.Result)
+	}
+	expected := map[string]string{"head": app.BrokerResultSuccess, "parent": app.BrokerResultFailure,
+		"grandparent": app.CommitResultNoWorkflows}
+	if !reflect.DeepEqual(resultResponse.CommitResults, expected) {
+		t.Errorf("expected commit results %v, but got %v", expected, resultResponse.CommitResults)
+	}
+	if resultResponse.ResultDetails[1].WorkflowCommit != "parent" {
+		t.Errorf("expected workflow commit parent, but got %s", resultResponse.ResultDetails[1].WorkflowCommit)
+	}
+
+	singleCommitResponse := broker.ResponseMessage{Response: app.BrokerResponseFinished}
+	gas.updateResponseResults(&singleCommitResponse, []app.WorkflowResult{
+		{WorkflowID: "1", WorkflowName: "BuildTest", Status: githubops.WorkflowStatusCompleted,
+			Result: githubops.WorkflowResultSuccess, Commit: "head"},
+	}, []string{"head"})
+	if singleCommitResponse.CommitResults != nil {
+		t.Errorf("expected no commit results, but got %v", singleCommitResponse.CommitResults)
+	}
+}
+
+// RunningGitHubActions reports a workflow still in progress for the commit "running" and none for the others.
+type RunningGitHubActions struct {
+	MockGitHubActions
+	calls map[string]int
+}
+
+func (g *RunningGitHubActions) GetRepoCommitWorkflowsResults(ctx context.Context, githubUsername, githubRepo,
+	githubCommit string, filter app.WorkflowRunsFilter) ([]app.WorkflowResult, error) {
+	g.calls[githubCommit]++
+	if !strings.HasPrefix(githubCommit, "running") {
+		return nil, nil
+	}
+	return []app.WorkflowResult{
+		{WorkflowID: githubCommit, WorkflowName: "build", Status: githubops.WorkflowStatusInProgress},
+	}, nil
+}
+
+func TestGitHubActions_WaitRepoCommitWorkflowsSharesPollTimeout(t *testing.T) {
+	gitHubActions := RunningGitHubActions{calls: map[string]int{}}
+	gas := &GitHubActionsServer{
+		App: &App{
+			Config: AppConfig{WorkflowsPollTimoutSecs: 1},
+			Logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+		},
+		Broker:        &MockBroker{},
+		GitHubActions: &gitHubActions,
+	}
+	ctx := context.WithValue(context.Background(), app.EventUUIDKey, "event-uuid-push-0")
+	setup := &app.GitHubActionsSettings{GitHubUsername: "repo_user", GitHubRepo: "repo_name"}
+	commits := []string{"running-head", "empty", "running-parent"}
+
+	start := time.Now()
+	workflowsResult, timedOut, err := gas.waitRepoCommitWorkflows(ctx, setup, &broker.RequestMessage{}, commits)
+	elapsed := time.Since(start)
+	if err != nil || !timedOut {
+		t.Fatalf("waitRepoCommitWorkflows() got timed out %v, error %v, want timed out", timedOut, err)
+	}
+	if elapsed >= 2*time.Second {
+		t.Errorf("waitRepoCommitWorkflows() took %s, want a single poll timeout for all commits", elapsed)
+	}
+	if len(workflowsResult) != 2 || workflowsResult[0].Commit != "running-head" ||
+		workflowsResult[1].Commit != "running-parent" {
+		t.Errorf("waitRepoCommitWorkflows() got %+v, want the workflows of both running commits", workflowsResult)
+	}
+	for _, commit := range commits {
+		if gitHubActions.calls[commit] != 1 {
+			t.Errorf("waitRepoCommitWorkflows() queried commit %s %d times, want once", commit,
+				gitHubActions.calls[commit])
+		}
+	}
+}
+
+func TestCommitsToCheck(t *testing.T) {
+	pushEvent := &broker.RequestPushEventMessage{After: "c4", Commits: []string{"c1", "c2", "c3", "c4"}}
+	cases := []struct {
+		name     string
+		config   AppConfig
+		request  broker.RequestMessage
+		expected []string
+	}{
+		{
+			name:     "only the head commit is checked by default",
+			request:  broker.RequestMessage{Commit: "c4", PushEvent: pushEvent},
+			expected: []string{"c4"},
+		},
+		{
+			name:     "every pushed commit is checked newest first",
+			config:   AppConfig{PushCheckAllCommits: true},
+			request:  broker.RequestMessage{Commit: "c4", PushEvent: pushEvent},
+			expected: []string{"c4", "c3", "c2", "c1"},
+		},
+		{
+			name:     "pushed commits are bounded",
+			config:   AppConfig{PushCheckAllCommits: true, PushMaxCommits: 2},
+			request:  broker.RequestMessage{Commit: "c4", PushEvent: pushEvent},
+			expected: []string{"c4", "c3"},
+		},
+		{
+			name:   "patch revisions are checked alone",
+			config: AppConfig{PushCheckAllCommits: true},
+			request: broker.RequestMessage{Commit: "c4",
+				PatchEvent: &broker.RequestPatchEventMessage{Action: "updated"}},
+			expected: []string{"c4"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gas := GitHubActionsServer{App: &App{Config: tc.config,
+				Logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{}))}}
+			if commits := gas.commitsToCheck(&tc.request); !reflect.DeepEqual(commits, tc.expected) {
+				t.Errorf("expected commits %v, but got %v", tc.expected, commits)
+			}
+		})
+	}
+}
+
+func TestEventPolicy(t *testing.T) {
+	policies, err := app.ParseEventPolicies("patch.merged=cleanup, tag=skip")
+	if err != nil {
+		t.Fatalf("ParseEventPolicies() error = %v", err)
+	}
+	if _, err := app.ParseEventPolicies("patch.merged=ignore"); err == nil {
+		t.Fatalf("ParseEventPolicies() expected error for unknown policy")
+	}
+	commit := "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7"
+	cases := []struct {
+		name           string
+		request        broker.RequestMessage
+		expectedKind   broker.EventKind
+		expectedPolicy app.EventPolicy
+	}{
+		{
+			name: "branch push is checked",
+			request: broker.RequestMessage{PushEvent: &broker.RequestPushEventMessage{After: commit,
+				Branch: "main"}},
+			expectedKind:   broker.EventKindPush,
+			expectedPolicy: app.EventPolicyCheck,
+		},
+		{
+			name: "tag push follows the configured policy",
+			request: broker.RequestMessage{PushEvent: &broker.RequestPushEventMessage{After: commit,
+				Branch: "refs/tags/v1.0.0"}},
+			expectedKind:   broker.EventKindTag,
+			expectedPolicy: app.EventPolicySkip,
+		},
+		{
+			name: "branch deletion is skipped",
+			request: broker.RequestMessage{PushEvent: &broker.RequestPushEventMessage{After: broker.ZeroCommit,
+				Branch: "feature"}},
+			expectedKind:   broker.EventKindBranchDeleted,
+			expectedPolicy: app.EventPolicySkip,
+		},
+		{
+			name:           "updated patch is checked",
+			request:        broker.RequestMessage{PatchEvent: &broker.RequestPatchEventMessage{Action: "updated"}},
+			expectedKind:   broker.EventKindPatchUpdated,
+			expectedPolicy: app.EventPolicyCheck,
+		},
+		{
+			name:           "merged patch follows the configured policy",
+			request:        broker.RequestMessage{PatchEvent: &broker.RequestPatchEventMessage{Action: "merged"}},
+			expectedKind:   broker.EventKindPatchMerged,
+			expectedPolicy: app.EventPolicyCleanup,
+		},
+		{
+			name:           "unknown patch action is checked",
+			request:        broker.RequestMessage{PatchEvent: &broker.RequestPatchEventMessage{Action: "redacted"}},
+			expectedKind:   broker.EventKind("patch.redacted"),
+			expectedPolicy: app.EventPolicyCheck,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gas := GitHubActionsServer{App: &App{Config: AppConfig{EventPolicies: policies}}}
+			if kind := tc.request.EventKind(); kind != tc.expectedKind {
+				t.Errorf("expected event kind %s, but got %s", tc.expectedKind, kind)
+			}
+			if policy := gas.eventPolicy(&tc.request); policy != tc.expectedPolicy {
+				t.Errorf("expected policy %s, but got %s", tc.expectedPolicy, policy)
+			}
+		})
+	}
+}
+
+func TestEventLabel(t *testing.T) {
+	cases := []struct {
+		kind     broker.EventKind
+		expected string
+	}{
+		{kind: broker.EventKindPush, expected: "push"},
+		{kind: broker.EventKindBranchDeleted, expected: "branch.deleted"},
+		{kind: broker.EventKindPatchUpdated, expected: "patch.updated"},
+		{kind: broker.EventKind("patch.redacted"), expected: "patch.other"},
+		{kind: broker.EventKind("patch.anything-else"), expected: "patch.other"},
+	}
+	for _, tc := range cases {
+		if label := eventLabel(tc.kind); label != tc.expected {
+			t.Errorf("eventLabel(%s) got %s, want %s", tc.kind, label, tc.expected)
+		}
+	}
+}
+
+func TestGitHubActions_ServeCleanup(t *testing.T) {
+	jobStore := MockJobStore{jobs: map[string]jobs.Job{
+		"previous": {ID: "previous", Repo: "repo_id", Commit: "1", PatchID: "patch_id",
+			RevisionID: "revision_id", CommentID: "previous_comment", Phase: jobs.JobPhaseFinished},
+		"retry": {ID: "retry", Repo: "repo_id", Commit: "1", PatchID: "patch_id",
+			RevisionID: "revision_id", CommentID: "previous_comment", Phase: jobs.JobPhaseFinished},
+		"other-patch": {ID: "other-patch", Repo: "repo_id", Commit: "1", PatchID: "other_patch_id",
+			RevisionID: "revision_id", CommentID: "other_comment", Phase: jobs.JobPhaseFinished},
+	}}
+	radiclePatch := MockRadiclePatch{t: t}
+	mockMetrics := MockMetrics{}
+	mockBroker := MockBroker{}
+	gas := &GitHubActionsServer{
+		App: &App{
+			Config: AppConfig{
+				WorkflowsStartLagSecs:   60,
+				WorkflowsPollTimoutSecs: 60,
+				EventPolicies:           map[broker.EventKind]app.EventPolicy{"patch.created": app.EventPolicyCleanup},
+				StatusPageURL:           "http://127.0.0.1:8090",
+			},
+			Logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+		},
+		Broker:        &mockBroker,
+		GitHubActions: &MockGitHubActions{},
+		Radicle:       &radiclePatch,
+		JobStore:      &jobStore,
+		Metrics:       &mockMetrics,
+	}
+	ctx := context.WithValue(context.WithValue(context.Background(), app.EventUUIDKey, "event-uuid-patch-valid-1"),
+		app.RepoClonePathKey, "event-uuid-patch-valid-1")
+	if err := gas.Serve(ctx); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+	if !reflect.DeepEqual(radiclePatch.RedactedComments, []string{"previous_comment"}) {
+		t.Errorf("Serve() redacted comments %v, want [previous_comment]", radiclePatch.RedactedComments)
+	}
+	if len(radiclePatch.Comments) != 0 {
+		t.Errorf("Serve() added comments %v, want none", radiclePatch.Comments)
+	}
+	if jobStore.jobs["previous"].CommentID != "" || jobStore.jobs["retry"].CommentID != "" ||
+		jobStore.jobs["other-patch"].CommentID != "other_comment" {
+		t.Errorf("Serve() got jobs %+v, want only the patch's comment forgotten", jobStore.jobs)
+	}
+	job := jobStore.jobs["event-uuid-patch-valid-1"]
+	if job.Phase != jobs.JobPhaseFinished || job.Result != jobs.JobResultSkipped {
+		t.Errorf("Serve() got job phase %s result %s, want %s %s", job.Phase, job.Result, jobs.JobPhaseFinished,
+			jobs.JobResultSkipped)
+	}
+	if !reflect.DeepEqual(mockMetrics.Jobs, []string{"patch.created skipped"}) {
+		t.Errorf("Serve() observed jobs %v, want [patch.created skipped]", mockMetrics.Jobs)
+	}
+	infoURL := "http://127.0.0.1:8090/jobs/event-uuid-patch-valid-1"
+	expectedResponses := []broker.ResponseMessage{
+		{Response: app.BrokerResponseTriggered, RunID: &broker.RunID{ID: "event-uuid-patch-valid-1"}, InfoURL: infoURL},
+		{Response: app.BrokerResponseFinished, Result: app.BrokerResultSuccess, InfoURL: infoURL, Skipped: true},
+	}
+	if !reflect.DeepEqual(mockBroker.Responses, expectedResponses) {
+		t.Errorf("Serve() got responses %+v, want %+v", mockBroker.Responses, expectedResponses)
+	}
+}
+
+func TestGitHubActions_ServeTrackingIssue(t *testing.T) {
+	cases := []struct {
+		name             string
+		trackingIssues   map[string]string
+		expectedComments int
+	}{
+		{
+			name:             "push to a branch with a tracking issue is commented on",
+			trackingIssues:   map[string]string{"main": "issue_id"},
+			expectedComments: 2,
+		},
+		{
+			name:           "push to a branch without a tracking issue is not commented on",
+			trackingIssues: map[string]string{"release": "issue_id"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			jobStore := MockJobStore{jobs: map[string]jobs.Job{}}
+			radicleIssue := MockRadicleIssue{}
+			gas := &GitHubActionsServer{
+				App: &App{
+					Config: AppConfig{
+						WorkflowsStartLagSecs:   1,
+						WorkflowsPollTimoutSecs: 1,
+						PushTrackingIssues:      tc.trackingIssues,
+					},
+					Logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+				},
+				Broker:        &MockBroker{},
+				GitHubActions: &MockGitHubActions{},
+				Radicle:       &MockRadiclePatch{t: t},
+				Issues:        &radicleIssue,
+				JobStore:      &jobStore,
+			}
+			ctx := context.WithValue(context.WithValue(context.Background(), app.EventUUIDKey,
+				"event-uuid-push-valid-1"), app.RepoClonePathKey, "event-uuid-push-valid-1")
+			if err := gas.Serve(ctx); err != nil {
+				t.Fatalf("Serve() error = %v", err)
+			}
+			if len(radicleIssue.Comments) != tc.expectedComments {
+				t.Fatalf("Serve() got issue comments %v, want %d", radicleIssue.Comments, tc.expectedComments)
+			}
+			for _, comment := range radicleIssue.Comments {
+				if !strings.HasPrefix(comment, "Push of `1` to `main`") {
+					t.Errorf("Serve() got issue comment %q, want it to name the pushed commit and branch", comment)
+				}
+			}
+			job := jobStore.jobs["event-uuid-push-valid-1"]
+			if tc.expectedComments > 0 && (job.IssueID != "issue_id" || job.CommentID != "issue_comment_id") {
+				t.Errorf("Serve() got job issue %s comment %s, want issue_id issue_comment_id", job.IssueID,
+					job.CommentID)
+			}
+		})
+	}
+}
+
+func TestGitHubActions_ServeFailureIssue(t *testing.T) {
+	previousJob := func(issueID string) map[string]jobs.Job {
+		return map[string]jobs.Job{
+			"previous": {ID: "previous", Repo: "repo_id", Commit: "2", Branch: "main", FailureIssueID: issueID,
+				Phase: jobs.JobPhaseFinished, CreatedAt: time.Now().Add(-time.Hour)},
+			"older": {ID: "older", Repo: "repo_id", Commit: "3", Branch: "main", FailureIssueID: "older_issue_id",
+				Phase: jobs.JobPhaseFinished, CreatedAt: time.Now().Add(-2 * time.Hour)},
+		}
+	}
+	cases := []struct {
+		name            string
+		disabled        bool
+		eventUUID       string
+		storedJobs      map[string]jobs.Job
+		openIssues      []string
+		expectedActions []string
+		expectedIssueID string
+	}{
+		{
+			name:            "failure opens a new issue",
+			eventUUID:       "event-uuid-push-valid-1",
+			storedJobs:      map[string]jobs.Job{},
+			expectedActions: []string{"create CI failing on main"},
+			expectedIssueID: "failure_issue_id",
+		},
+		{
+			name:            "failure reopens and comments on the closed issue",
+			eventUUID:       "event-uuid-push-valid-1",
+			storedJobs:      previousJob("issue_id"),
+			openIssues:      []string{"older_issue_id"},
+			expectedActions: []string{"open issue_id true", "comment issue_id"},
+			expectedIssueID: "issue_id",
+		},
+		{
+			name:            "failure comments on the open issue",
+			eventUUID:       "event-uuid-push-valid-1",
+			storedJobs:      previousJob("issue_id"),
+			openIssues:      []string{"issue_id"},
+			expectedActions: []string{"comment issue_id"},
+			expectedIssueID: "issue_id",
+		},
+		{
+			name:            "success comments on and closes the open issue",
+			eventUUID:       "event-uuid-push-valid-0",
+			storedJobs:      previousJob("issue_id"),
+			openIssues:      []string{"issue_id"},
+			expectedActions: []string{"comment issue_id", "open issue_id false"},
+			expectedIssueID: "issue_id",
+		},
+		{
+			name:            "success leaves the issue closed by hand",
+			eventUUID:       "event-uuid-push-valid-0",
+			storedJobs:      previousJob("issue_id"),
+			expectedIssueID: "issue_id",
+		},
+		{
+			name:            "unknown issue state keeps the issue for the next push",
+			eventUUID:       "event-uuid-push-valid-1",
+			storedJobs:      previousJob("unreachable_issue_id"),
+			expectedIssueID: "unreachable_issue_id",
+		},
+		{
+			name:       "success without issue does nothing",
+			eventUUID:  "event-uuid-push-valid-0",
+			storedJobs: map[string]jobs.Job{},
+		},
+		{
+			name:       "failure does nothing when disabled",
+			disabled:   true,
+			eventUUID:  "event-uuid-push-valid-1",
+			storedJobs: map[string]jobs.Job{},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			jobStore := MockJobStore{jobs: tc.storedJobs}
+			radicleIssue := MockRadicleIssue{OpenIssues: tc.openIssues}
+			gas := &GitHubActionsServer{
+				App: &App{
+					Config: AppConfig{
+						WorkflowsStartLagSecs:      1,
+						WorkflowsPollTimoutSecs:    1,
+						DefaultBranchFailureIssues: !tc.disabled,
+					},
+					Logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+				},
+				Broker:        &MockBroker{},
+				GitHubActions: &MockGitHubActions{},
+				Radicle:       &MockRadiclePatch{t: t},
+				Issues:        &radicleIssue,
+				JobStore:      &jobStore,
+			}
+			ctx := context.WithValue(context.WithValue(context.Background(), app.EventUUIDKey, tc.eventUUID),
+				app.RepoClonePathKey, tc.eventUUID)
+			if err := gas.Serve(ctx); err != nil {
+				t.Fatalf("Serve() error = %v", err)
+			}
+			if !reflect.DeepEqual(radicleIssue.Actions, tc.expectedActions) {
+				t.Errorf("Serve() got issue actions %v, want %v", radicleIssue.Actions, tc.expectedActions)
+			}
+			job := jobStore.jobs[tc.eventUUID]
+			if job.FailureIssueID != tc.expectedIssueID {
+				t.Errorf("Serve() got failure issue %s, want %s", job.FailureIssueID, tc.expectedIssueID)
+			}
+		})
+	}
+}
+
+func TestGitHubActions_ServePatchLabels(t *testing.T) {
+	patchLabels, err := app.ParsePatchLabels("pending=ci:pending, passing=ci:passing, failing=ci:failing")
+	if err != nil {
+		t.Fatalf("ParsePatchLabels() error = %v", err)
+	}
+	if _, err := app.ParsePatchLabels("running=ci:running"); err == nil {
+		t.Fatalf("ParsePatchLabels() expected error for unknown state")
+	}
+	cases := []struct {
+		name           string
+		patchLabels    app.PatchLabels
+		eventUUID      string
+		expectedLabels [][]string
+	}{
+		{
+			name:           "failing patch is labeled pending then failing",
+			patchLabels:    patchLabels,
+			eventUUID:      "event-uuid-patch-valid-1",
+			expectedLabels: [][]string{{"bug", "ci:pending"}, {"bug", "ci:failing"}},
+		},
+		{
+			name:           "passing patch is labeled pending then passing",
+			patchLabels:    patchLabels,
+			eventUUID:      "event-uuid-patch-valid-0",
+			expectedLabels: [][]string{{"bug", "ci:pending"}, {"bug", "ci:passing"}},
+		},
+		{
+			name:           "unchanged labels are not updated",
+			patchLabels:    app.PatchLabels{Failing: "ci:failing"},
+			eventUUID:      "event-uuid-patch-valid-1",
+			expectedLabels: [][]string{{"bug"}, {"bug", "ci:failing"}},
+		},
+		{
+			name:      "patch is not labeled without configured labels",
+			eventUUID: "event-uuid-patch-valid-1",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			radiclePatch := MockRadiclePatch{TotalComments: 10, t: t}
+			gas := &GitHubActionsServer{
+				App: &App{
+					Config: AppConfig{
+						WorkflowsStartLagSecs:   1,
+						WorkflowsPollTimoutSecs: 1,
+						PatchLabels:             tc.patchLabels,
+					},
+					Logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+				},
+				Broker:        &MockBroker{},
+				GitHubActions: &MockGitHubActions{},
+				Radicle:       &radiclePatch,
+			}
+			ctx := context.WithValue(context.WithValue(context.Background(), app.EventUUIDKey, tc.eventUUID),
+				app.RepoClonePathKey, tc.eventUUID)
+			if err := gas.Serve(ctx); err != nil {
+				t.Fatalf("Serve() error = %v", err)
+			}
+			if !reflect.DeepEqual(radiclePatch.Labels, tc.expectedLabels) {
+				t.Errorf("Serve() got patch labels %v, want %v", radiclePatch.Labels, tc.expectedLabels)
+			}
+		})
+	}
+}
+
+func TestGitHubActions_ServeAnnotations(t *testing.T) {
+	mainComment := "1 main.go:3-4 **warning** lint (GitHub Actions work 0)  \nunused\nvariable"
+	utilComment := "1 util.go:10-13 **failure** (GitHub Actions work 0)  \ntest failed"
+	mainListed := "\t\t - warning `main.go:3`: lint: unused variable"
+	utilListed := "\t\t - failure `util.go:10-12`: test failed"
+	runListed := "\t\t - failure: Process completed with exit code 1."
+	cases := []struct {
+		name                 string
+		eventUUID            string
+		maxComments          uint64
+		expectedCodeComments []string
+		expectedListed       []string
+		expectedNotListed    []string
+	}{
+		{
+			name:                 "annotations of files are commented on the patch",
+			eventUUID:            "event-uuid-patch-annotations-1",
+			maxComments:          10,
+			expectedCodeComments: []string{mainComment, utilComment},
+			expectedListed:       []string{"Annotations:", runListed},
+			expectedNotListed:    []string{mainListed, utilListed},
+		},
+		{
+			name:                 "annotations over the max are listed",
+			eventUUID:            "event-uuid-patch-annotations-1",
+			maxComments:          1,
+			expectedCodeComments: []string{mainComment},
+			expectedListed:       []string{utilListed, runListed},
+			expectedNotListed:    []string{mainListed},
+		},
+		{
+			name:           "annotations are listed without code comments",
+			eventUUID:      "event-uuid-patch-annotations-1",
+			expectedListed: []string{mainListed, utilListed, runListed},
+		},
+		{
+			name:              "patches without annotations list none",
+			eventUUID:         "event-uuid-patch-valid-1",
+			maxComments:       10,
+			expectedNotListed: []string{"Annotations:"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			radiclePatch := MockRadiclePatch{TotalComments: 10, t: t}
+			gas := &GitHubActionsServer{
+				App: &App{
+					Config: AppConfig{
+						WorkflowsStartLagSecs:   1,
+						WorkflowsPollTimoutSecs: 1,
+						AnnotationsMaxComments:  tc.maxComments,
+					},
+					Logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+				},
+				Broker:        &MockBroker{},
+				GitHubActions: &MockGitHubActions{},
+				Radicle:       &radiclePatch,
+			}
+			ctx := context.WithValue(context.WithValue(context.Background(), app.EventUUIDKey, tc.eventUUID),
+				app.RepoClonePathKey, tc.eventUUID)
+			if err := gas.Serve(ctx); err != nil {
+				t.Fatalf("Serve() error = %v", err)
+			}
+			if !reflect.DeepEqual(radiclePatch.CodeComments, tc.expectedCodeComments) {
+				t.Errorf("Serve() got code comments %q, want %q", radiclePatch.CodeComments, tc.expectedCodeComments)
+			}
+			resultComment := radiclePatch.Comments[len(radiclePatch.Comments)-1]
+			for _, listed := range tc.expectedListed {
+				if !strings.Contains(resultComment, listed) {
+					t.Errorf("Serve() result comment %q does not list %q", resultComment, listed)
+				}
+			}
+			for _, notListed := range tc.expectedNotListed {
+				if strings.Contains(resultComment, notListed) {
+					t.Errorf("Serve() result comment %q lists %q", resultComment, notListed)
+				}
+			}
+		})
+	}
+}
+
+func TestGitHubActions_AddTestSummaries(t *testing.T) {
+	gas := GitHubActionsServer{
+		App: &App{
+			Config: AppConfig{WorkflowsPollTimoutSecs: 1800},
+			Logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+		},
+		GitHubActions: &MockGitHubActions{},
+	}
+	response := func() broker.ResponseMessage {
+		return broker.ResponseMessage{
+			Result: app.BrokerResultFailure,
+			ResultDetails: []broker.WorkflowDetails{
+				{WorkflowID: "1", WorkflowName: "BuildTest", WorkflowResult: string(githubops.WorkflowResultFailure),
+					WorkflowArtifacts: []broker.WorkflowArtifact{
+						{Id: "1", Name: "junit-unit"}, {Id: "2", Name: "coverage"}, {Id: "3", Name: "junit-e2e"},
+						{Id: "4", Name: "junit-broken"},
+					}},
+				{WorkflowID: "2", WorkflowName: "Lint", WorkflowResult: string(githubops.WorkflowResultSuccess)},
+			},
+		}
+	}
+	failedTests := []broker.FailedTest{{Name: "pkg.TestFail", Message: "expected 1,\n got 2"}}
+	for i := 0; i < maxListedFailedTests-1; i++ {
+		failedTests = append(failedTests, broker.FailedTest{Name: "pkg.TestFail" + strconv.Itoa(i)})
+	}
+	cases := []struct {
+		name            string
+		pattern         string
+		expectedSummary *broker.TestSummary
+		expectedComment []string
+	}{
+		{
+			name:    "test summaries of the matching artifacts are added with the first failed tests",
+			pattern: "junit-*",
+			expectedSummary: &broker.TestSummary{Total: 15, Passed: 3, Failed: 11, Skipped: 1, Flaky: 1,
+				FailedTests: failedTests, MoreFailedTests: 2},
+			expectedComment: []string{
+				"\n\t Tests: 15 run, 3 passed, 11 failed, 1 skipped, 1 flaky  \n\t\t " +
+					"- failed `pkg.TestFail`: expected 1, got 2  \n\t\t - failed `pkg.TestFail0`  \n",
+				"- failed `pkg.TestFail8`  \n\t\t - and 2 more  \n\t Artifacts:",
+			},
+		},
+		{
+			name:            "test summaries are not added without a pattern",
+			expectedComment: []string{"[❌](# \"failure\")  \n\t Artifacts:"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), app.EventUUIDKey, "event-uuid-patch-valid-1")
+			settings := app.GitHubActionsSettings{GitHubUsername: "repo_user", GitHubRepo: "repo_name",
+				TestReportArtifacts: tc.pattern}
+			resultResponse := response()
+			gas.addTestSummaries(ctx, &settings, &resultResponse)
+			if !reflect.DeepEqual(resultResponse.ResultDetails[0].WorkflowTestSummary, tc.expectedSummary) {
+				t.Errorf("addTestSummaries() got %+v, want %+v", resultResponse.ResultDetails[0].WorkflowTestSummary,
+					tc.expectedSummary)
+			}
+			if resultResponse.ResultDetails[1].WorkflowTestSummary != nil {
+				t.Errorf("addTestSummaries() added a summary to a workflow without test reports")
+			}
+			comment := gas.preparePatchCommentResultMessage(resultResponse, settings)
+			for _, expected := range tc.expectedComment {
+				if !strings.Contains(comment, expected) {
+					t.Errorf("preparePatchCommentResultMessage() got %q, want it to contain %q", comment, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestGitHubActions_AddCoverage(t *testing.T) {
+	response := func() broker.ResponseMessage {
+		return broker.ResponseMessage{
+			Response: app.BrokerResponseFinished,
+			Result:   app.BrokerResultSuccess,
+			ResultDetails: []broker.WorkflowDetails{
+				{WorkflowID: "1", WorkflowName: "BuildTest", WorkflowResult: string(githubops.WorkflowResultSuccess),
+					WorkflowArtifacts: []broker.WorkflowArtifact{
+						{Id: "1", Name: "junit-unit"}, {Id: "2", Name: "coverage"}, {Id: "4", Name: "coverage-broken"},
+					}},
+				{WorkflowID: "2", WorkflowName: "Web", WorkflowResult: string(githubops.WorkflowResultSuccess),
+					WorkflowArtifacts: []broker.WorkflowArtifact{{Id: "5", Name: "coverage-web"}}},
+			},
+		}
+	}
+	patchMessage := &broker.RequestMessage{Repo: "repo_id", PatchEvent: &broker.RequestPatchEventMessage{
+		Patch:      broker.PatchDetails{ID: "patch_id", Target: "delegates"},
+		Repository: broker.Repository{DefaultBranch: "main"},
+	}}
+	pushMessage := &broker.RequestMessage{Repo: "repo_id", PushEvent: &broker.RequestPushEventMessage{
+		Branch: "refs/heads/dev",
+	}}
+	coverage := func(percent float64) *float64 {
+		return &percent
+	}
+	// Computed at run time like the coverage, whose rounding differs from constant expressions.
+	var covered, total float64 = 80, 120
+	now := time.Now()
+	storedJobs := map[string]jobs.Job{
+		"main-latest": {ID: "main-latest", Repo: "repo_id", Branch: "main", Coverage: coverage(70),
+			CreatedAt: now.Add(-time.Hour)},
+		"main-older": {ID: "main-older", Repo: "repo_id", Branch: "main", Coverage: coverage(90),
+			CreatedAt: now.Add(-2 * time.Hour)},
+		"main-without-coverage": {ID: "main-without-coverage", Repo: "repo_id", Branch: "main", CreatedAt: now},
+		"patch": {ID: "patch", Repo: "repo_id", PatchID: "other_patch_id", Coverage: coverage(10),
+			CreatedAt: now},
+		"other-repo": {ID: "other-repo", Repo: "other_repo_id", Branch: "main", Coverage: coverage(20),
+			CreatedAt: now},
+	}
+	cases := []struct {
+		name             string
+		message          *broker.RequestMessage
+		pattern          string
+		threshold        float64
+		expectedCoverage *broker.Coverage
+		expectedResult   string
+		expectedComment  string
+	}{
+		{
+			name:    "coverage of the matching artifacts is compared with the target branch",
+			message: patchMessage,
+			pattern: "coverage*",
+			expectedCoverage: &broker.Coverage{Covered: 80, Total: 120, Percent: covered * 100 / total,
+				BaseBranch: "main", BasePercent: coverage(70), Delta: coverage(covered*100/total - 70)},
+			expectedResult:  app.BrokerResultSuccess,
+			expectedComment: "\n\nCoverage: 66.67% (-3.33% vs `main`)\n\n|",
+		},
+		{
+			name:      "coverage below the threshold fails the result",
+			message:   patchMessage,
+			pattern:   "coverage",
+			threshold: 80,
+			expectedCoverage: &broker.Coverage{Covered: 75, Total: 100, Percent: 75, BaseBranch: "main",
+				BasePercent: coverage(70), Delta: coverage(5), Threshold: 80},
+			expectedResult:  app.BrokerResultFailure,
+			expectedComment: "\n\nCoverage: 75.00% (+5.00% vs `main`) ❌ below the 80.00% threshold\n\n|",
+		},
+		{
+			name:             "coverage without recorded coverage of the branch is not compared",
+			message:          pushMessage,
+			pattern:          "coverage-web",
+			threshold:        10,
+			expectedCoverage: &broker.Coverage{Covered: 5, Total: 20, Percent: 25, BaseBranch: "dev", Threshold: 10},
+			expectedResult:   app.BrokerResultSuccess,
+			expectedComment:  "\n\nCoverage: 25.00%\n\n|",
+		},
+		{
+			name:           "coverage is not added without a pattern",
+			message:        patchMessage,
+			expectedResult: app.BrokerResultSuccess,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gas := GitHubActionsServer{
+				App: &App{
+					Config: AppConfig{WorkflowsPollTimoutSecs: 1800},
+					Logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+				},
+				GitHubActions: &MockGitHubActions{},
+				JobStore:      &MockJobStore{jobs: maps.Clone(storedJobs)},
+				job:           jobs.Job{ID: "current"},
+			}
+			settings := app.GitHubActionsSettings{GitHubUsername: "repo_user", GitHubRepo: "repo_name",
+				CoverageArtifacts: tc.pattern, CoverageThreshold: tc.threshold}
+			resultResponse := response()
+			gas.addCoverage(context.Background(), tc.message, &settings, &resultResponse)
+			if !reflect.DeepEqual(resultResponse.Coverage, tc.expectedCoverage) {
+				t.Errorf("addCoverage() got %+v, want %+v", resultResponse.Coverage, tc.expectedCoverage)
+			}
+			if resultResponse.Result != tc.expectedResult {
+				t.Errorf("addCoverage() got result %s, want %s", resultResponse.Result, tc.expectedResult)
+			}
+			if tc.expectedCoverage == nil {
+				if gas.job.Coverage != nil {
+					t.Errorf("addCoverage() recorded coverage %v in the job", *gas.job.Coverage)
+				}
+				return
+			}
+			if gas.job.Coverage == nil || *gas.job.Coverage != tc.expectedCoverage.Percent {
+				t.Errorf("addCoverage() recorded coverage %v in the job, want %v", gas.job.Coverage,
+					tc.expectedCoverage.Percent)
+			}
+			comment := gas.preparePatchCommentResultMessage(resultResponse, settings)
+			if !strings.Contains(comment, tc.expectedComment) {
+				t.Errorf("preparePatchCommentResultMessage() got %q, want it to contain %q", comment,
+					tc.expectedComment)
+			}
+		})
+	}
+}
+
+func TestGitHubActions_CommentTemplates(t *testing.T) {
+	templatesDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(templatesDir, "z3gqcJUoA1n9HaHKufZs5FCSGazv5.tmpl"),
+		[]byte("{{.Status}} for the repo"), 0o600)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(templatesDir, "default.tmpl"), []byte("{{.Status}} for the node"), 0o600)
+	}
+	if err != nil {
+		t.Fatalf("could not write templates: %v", err)
+	}
+	createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	updatedAt := createdAt.Add(5 * time.Minute)
+	response := broker.ResponseMessage{
+		Response: app.BrokerResponseFinished,
+		Result:   app.BrokerResultFailure,
+		ResultDetails: []broker.WorkflowDetails{
+			{WorkflowID: "1", WorkflowName: "BuildTest", WorkflowResult: string(githubops.WorkflowResultTimedOut),
+				WorkflowCreatedAt: &createdAt, WorkflowUpdatedAt: &updatedAt,
+				WorkflowArtifacts: []broker.WorkflowArtifact{{Id: "7", Name: "binary", Url: "artifact_url"}}},
+		},
+	}
+	cases := []struct {
+		name     string
+		repoID   string
+		template string
+		expected string
+	}{
+		{
+			name:   "repo template renders the data model",
+			repoID: "rad:z3gqcJUoA1n9HaHKufZs5FCSGazv5",
+			template: "{{.Status}}:{{range .Workflows}} {{.Name}} {{.Icon}} {{.Label}} in {{.Duration}}" +
+				"{{range .Artifacts}} {{.Name}} {{.URL}}{{end}}{{end}}",
+			expected: "failure: BuildTest ⌛ timed out in 5m binary artifact_url",
+		},
+		{
+			name:     "invalid template falls back to the default one",
+			repoID:   "rad:z3gqcJUoA1n9HaHKufZs5FCSGazv5",
+			template: "{{.Unknown}}",
+			expected: "GitHub Actions Result: failure ❌",
+		},
+		{
+			name:     "node template of the repo is used without repo template",
+			repoID:   "rad:z3gqcJUoA1n9HaHKufZs5FCSGazv5",
+			expected: "failure for the repo",
+		},
+		{
+			name:     "node default template is used for other repos",
+			repoID:   "rad:z4V1sjrXqjvFdnCUbxPFqd5p4DtH5",
+			expected: "failure for the node",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gas := GitHubActionsServer{App: &App{
+				Config: AppConfig{WorkflowsPollTimoutSecs: 1800, CommentTemplatesDir: templatesDir},
+				Logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+			}}
+			settings := app.GitHubActionsSettings{GitHubUsername: "testUser", GitHubRepo: "testRepo",
+				CommentTemplate: tc.template}
+			gas.loadCommentTemplate(tc.repoID, &settings)
+			comment := gas.preparePatchCommentResultMessage(response, settings)
+			if !strings.HasPrefix(comment, tc.expected) {
+				t.Errorf("expected comment starting with %q, but got %q", tc.expected, comment)
+			}
+		})
+	}
+}
@@ -0,0 +1,425 @@
+package serve
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"radicle-github-actions-adapter/app"
+	"radicle-github-actions-adapter/app/broker"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// defaultCommentTemplate renders the comments with the workflows' results unless the repo or the node provides a
+// template.
+const defaultCommentTemplate string = `GitHub Actions {{if .InProgress}}Status{{else}}Result{{end}}: {{if not .Workflows -}}
+No workflows found
+{{- else if .InProgress}}{{.Response}} ⏳
+{{- else if .TimedOut}}timed out ⏱️{{"  \n"}} *Workflows still running on GitHub after {{.PollTimeout}}.*
+{{- else}}{{.Result}} {{if eq .Result "success"}}✅{{else}}❌{{end}}
+{{- end}}
+{{- if .Workflows}}{{"  \n"}} Workflows:
+{{- range .Workflows}}{{"  \n"}} - {{.Name}} ([#{{.ID}}]({{.URL}})) [{{.Icon}}](# "{{.Label}}")
+{{- with .ShortCommit}} on ` + "`{{.}}`" + `{{end}}
+{{- with .RunDetails}} ({{.}}){{end}}
+{{- with .Tests}}{{"  \n\t"}} Tests: {{.Total}} run, {{.Passed}} passed, {{.Failed}} failed, {{.Skipped}} skipped
+{{- with .Flaky}}, {{.}} flaky{{end}}
+{{- range .FailedTests}}{{"  \n\t\t"}} - {{if .Flaky}}flaky{{else}}failed{{end}} ` + "`{{.Name}}`" + `
+{{- with .Message}}: {{.}}{{end}}{{end}}
+{{- with .MoreFailedTests}}{{"  \n\t\t"}} - and {{.}} more{{end}}
+{{- end}}
+{{- with .PreviousAttempts}}{{"  \n\t"}} Previous attempts:
+{{- range .}}{{"  \n\t\t"}} - [{{.Name}}]({{.URL}}) [{{.Icon}}](# "{{.Label}}"){{end}}
+{{- end}}
+{{- with .Artifacts}}{{"  \n\t"}} Artifacts:
+{{- range .}}{{"  \n\t\t"}} - {{.Name}} ([#{{.ID}}]({{.URL}})){{end}}
+{{- end}}
+{{- with .Annotations}}{{"  \n\t"}} Annotations:
+{{- range .}}{{"  \n\t\t"}} - {{.Level}}{{with .Location}} ` + "`{{.}}`" + `{{end}}:
+{{- with .Title}} {{.}}:{{end}} {{.Message}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- range .Commits}}{{if eq .Result "no_workflows"}}{{"  \n"}} - ` + "`{{.ShortCommit}}`" + ` has no workflows{{end}}
+{{- end}}
+{{- end}}
+{{- with .Coverage}}
+
+Coverage: {{.Percent}}{{with .Delta}} ({{.}} vs ` + "`{{$.Coverage.BaseBranch}}`" + `){{end}}
+{{- if .BelowThreshold}} ❌ below the {{.Threshold}} threshold{{end}}
+{{- end}}
+{{- if and .Finished .Workflows}}
+
+| Workflow | Conclusion | Duration | Queued | Run | Attempt |
+| --- | --- | --- | --- | --- | --- |
+{{- range .Workflows}}
+| {{.Name}} | {{.Icon}} {{.Label}} | {{or .Duration "-"}} | {{or .Queued "-"}} | {{or .RunNumber "-"}} | {{or .Attempt "-"}} |
+{{- end}}
+{{- if or .WallTime .BillableMinutes}}
+
+Total: {{or .WallTime "-"}} wall time
+{{- with .BillableMinutes}}, {{.}} billable minute{{if ne . 1}}s{{end}}{{end}}
+{{- end}}
+{{- end}}`
+
+// defaultCommentTemplateName and commentTemplateSuffix name the templates in CommentTemplatesDir: <RID>.tmpl for a
+// single repo, default.tmpl for all others.
+const (
+	defaultCommentTemplateName string = "default"
+	commentTemplateSuffix      string = ".tmpl"
+)
+
+// CommentData is the data model of the comment templates.
+type CommentData struct {
+	// Status is one of in_progress, no_workflows, timed_out, success and failure.
+	Status      string
+	Response    string
+	Result      string
+	InProgress  bool
+	Finished    bool
+	TimedOut    bool
+	PollTimeout string
+	InfoURL     string
+	// WallTime is the time from the trigger of the event to the finished response, BillableMinutes the GitHub
+	// billable minutes of all workflows, if GitHub reports them.
+	WallTime        string
+	BillableMinutes int
+	Workflows       []CommentWorkflow
+	// Commits are the checked commits of a finished response, when more than one commit is checked.
+	Commits []CommentCommit
+	// Coverage is the coverage of the commit, if the repo configures its coverage artifacts.
+	Coverage *CommentCoverage
+}
+
+// CommentCoverage is the coverage in the CommentData, with the percentages formatted like 81.25%. BasePercent and
+// Delta, like +1.10%, are empty when no coverage of BaseBranch is recorded, Threshold when the repo sets none.
+type CommentCoverage struct {
+	Percent        string
+	BaseBranch     string
+	BasePercent    string
+	Delta          string
+	Threshold      string
+	BelowThreshold bool
+}
+
+// CommentCommit is a checked commit in the CommentData. Result is success, failure or no_workflows.
+type CommentCommit struct {
+	Commit      string
+	ShortCommit string
+	Result      string
+}
+
+// CommentWorkflow is a workflow in the CommentData.
+type CommentWorkflow struct {
+	ID     string
+	Name   string
+	Result string
+	// Label is the Result in words, Icon its emoji.
+	Label  string
+	Icon   string
+	URL    string
+	Commit string
+	// ShortCommit is the first 7 characters of Commit, when more than one commit is checked.
+	ShortCommit string
+	RunDetails  string
+	RunNumber   int
+	Attempt     int
+	// Duration is the run time of the workflow, Queued the time it waited for a runner.
+	Duration         string
+	Queued           string
+	PreviousAttempts []CommentAttempt
+	Artifacts        []CommentArtifact
+	// Annotations are the annotations of a failed workflow that were not commented on the patch's code.
+	Annotations []CommentAnnotation
+	// Tests is the summary of the workflow's test reports, if any.
+	Tests *CommentTests
+}
+
+// CommentTests summarizes the test reports of a CommentWorkflow. FailedTests lists the first failed or flaky tests,
+// MoreFailedTests counts the others.
+type CommentTests struct {
+	Total           int
+	Passed          int
+	Failed          int
+	Skipped         int
+	Flaky           int
+	FailedTests     []CommentFailedTest
+	MoreFailedTests int
+}
+
+// CommentFailedTest is a failed or flaky test of CommentTests.
+type CommentFailedTest struct {
+	Name string
+	// Message is on a single line.
+	Message string
+	Flaky   bool
+}
+
+// CommentAttempt is a previous attempt of a CommentWorkflow.
+type CommentAttempt struct {
+	Name   string
+	Result string
+	Label  string
+	Icon   string
+	URL    string
+}
+
+// CommentAnnotation is an annotation of a CommentWorkflow.
+type CommentAnnotation struct {
+	Level string
+	// Location is the file and lines, e.g. main.go:3-5, empty when the annotation is about the run itself.
+	Location string
+	Title    string
+	// Message is on a single line.
+	Message string
+}
+
+// CommentArtifact is an artifact of a CommentWorkflow.
+type CommentArtifact struct {
+	ID   string
+	Name string
+	URL  string
+}
+
+// commentData returns the template data of the response's results.
+func (gas *GitHubActionsServer) commentData(resultResponse broker.ResponseMessage,
+	gitHubActionsSettings app.GitHubActionsSettings) CommentData {
+	data := CommentData{
+		Response:    resultResponse.Response,
+		Result:      resultResponse.Result,
+		InProgress:  resultResponse.Response == app.BrokerResponseInProgress,
+		Finished:    resultResponse.Response == app.BrokerResponseFinished,
+		TimedOut:    resultResponse.TimedOut,
+		PollTimeout: formatDuration(time.Second * time.Duration(gas.App.Config.WorkflowsPollTimoutSecs)),
+		InfoURL:     resultResponse.InfoURL,
+	}
+	switch {
+	case len(resultResponse.ResultDetails) == 0:
+		data.Status = "no_workflows"
+	case data.InProgress:
+		data.Status = "in_progress"
+	case data.TimedOut:
+		data.Status = "timed_out"
+	case resultResponse.Result == app.BrokerResultSuccess:
+		data.Status = app.BrokerResultSuccess
+	default:
+		data.Status = app.BrokerResultFailure
+	}
+	if data.Finished && !gas.job.CreatedAt.IsZero() {
+		data.WallTime = formatDuration(time.Since(gas.job.CreatedAt).Round(time.Second))
+	}
+	var billableMS int64
+	for _, result := range resultResponse.ResultDetails {
+		url := result.WorkflowURL
+		if len(url) == 0 {
+			url = "https://github.com/" + gitHubActionsSettings.GitHubUsername + "/" + gitHubActionsSettings.GitHubRepo +
+				"/actions/runs/" + result.WorkflowID
+		}
+		workflow := CommentWorkflow{
+			ID:         result.WorkflowID,
+			Name:       result.WorkflowName,
+			Result:     result.WorkflowResult,
+			Label:      strings.ReplaceAll(result.WorkflowResult, "_", " "),
+			Icon:       workflowResultIcon(result.WorkflowResult),
+			URL:        url,
+			Commit:     result.WorkflowCommit,
+			RunDetails: workflowRunDetails(result),
+			RunNumber:  result.WorkflowRunNumber,
+			Attempt:    result.WorkflowAttempt,
+		}
+		startedAt := result.WorkflowCreatedAt
+		if result.WorkflowStartedAt != nil {
+			startedAt = result.WorkflowStartedAt
+			// The creation time of a rerun is the one of its first attempt, so only the first attempt was queued for
+			// the time since.
+			if result.WorkflowCreatedAt != nil && result.WorkflowAttempt <= 1 {
+				workflow.Queued = formatDuration(result.WorkflowStartedAt.Sub(*result.WorkflowCreatedAt))
+			}
+		}
+		if startedAt != nil && result.WorkflowUpdatedAt != nil {
+			workflow.Duration = formatDuration(result.WorkflowUpdatedAt.Sub(*startedAt))
+		}
+		billableMS += result.WorkflowBillableMS
+		for _, attempt := range result.PreviousAttempts {
+			attemptName := "#" + attempt.RunID
+			if attempt.RunAttempt > 0 {
+				attemptName += " attempt " + strconv.Itoa(attempt.RunAttempt)
+			}
+			workflow.PreviousAttempts = append(workflow.PreviousAttempts, CommentAttempt{
+				Name:   attemptName,
+				Result: attempt.Result,
+				Label:  strings.ReplaceAll(attempt.Result, "_", " "),
+				Icon:   workflowResultIcon(attempt.Result),
+				URL:    attempt.Url,
+			})
+		}
+		for _, artifact := range result.WorkflowArtifacts {
+			workflow.Artifacts = append(workflow.Artifacts, CommentArtifact{
+				ID:   artifact.Id,
+				Name: artifact.Name,
+				URL:  artifact.Url,
+			})
+		}
+		for _, annotation := range result.WorkflowAnnotations {
+			if gas.codeAnnotations[annotation] {
+				continue
+			}
+			workflow.Annotations = append(workflow.Annotations, CommentAnnotation{
+				Level:    annotation.Level,
+				Location: annotationLocation(annotation),
+				Title:    annotation.Title,
+				Message:  singleLine(annotation.Message),
+			})
+		}
+		if result.WorkflowTestSummary != nil {
+			workflow.Tests = commentTests(*result.WorkflowTestSummary)
+		}
+		if len(resultResponse.CommitResults) > 1 {
+			workflow.ShortCommit = shortCommit(result.WorkflowCommit)
+		}
+		data.Workflows = append(data.Workflows, workflow)
+	}
+	data.Commits = commentCommits(resultResponse)
+	// GitHub bills every started minute.
+	data.BillableMinutes = int((billableMS + time.Minute.Milliseconds() - 1) / time.Minute.Milliseconds())
+	if resultResponse.Coverage != nil {
+		data.Coverage = commentCoverage(*resultResponse.Coverage)
+	}
+	return data
+}
+
+// commentCommits returns the template data of the checked commits, those with workflows in the order of their
+// workflows and the others sorted.
+func commentCommits(resultResponse broker.ResponseMessage) []CommentCommit {
+	if len(resultResponse.CommitResults) <= 1 {
+		return nil
+	}
+	var commits []string
+	for _, result := range resultResponse.ResultDetails {
+		if !slices.Contains(commits, result.WorkflowCommit) {
+			commits = append(commits, result.WorkflowCommit)
+		}
+	}
+	var others []string
+	for commit := range resultResponse.CommitResults {
+		if !slices.Contains(commits, commit) {
+			others = append(others, commit)
+		}
+	}
+	slices.Sort(others)
+	var commentCommits []CommentCommit
+	for _, commit := range append(commits, others...) {
+		commentCommits = append(commentCommits, CommentCommit{
+			Commit:      commit,
+			ShortCommit: shortCommit(commit),
+			Result:      resultResponse.CommitResults[commit],
+		})
+	}
+	return commentCommits
+}
+
+// shortCommit returns the first 7 characters of a commit hash.
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// commentCoverage returns the template data of the commit's coverage.
+func commentCoverage(coverage broker.Coverage) *CommentCoverage {
+	commentCoverage := &CommentCoverage{
+		Percent:    formatPercent(coverage.Percent),
+		BaseBranch: coverage.BaseBranch,
+	}
+	if coverage.BasePercent != nil && coverage.Delta != nil {
+		commentCoverage.BasePercent = formatPercent(*coverage.BasePercent)
+		commentCoverage.Delta = fmt.Sprintf("%+.2f%%", *coverage.Delta)
+	}
+	if coverage.Threshold > 0 {
+		commentCoverage.Threshold = formatPercent(coverage.Threshold)
+		commentCoverage.BelowThreshold = coverage.Percent < coverage.Threshold
+	}
+	return commentCoverage
+}
+
+// formatPercent formats a percentage with two decimals, e.g. 81.25%.
+func formatPercent(percent float64) string {
+	return strconv.FormatFloat(percent, 'f', 2, 64) + "%"
+}
+
+// commentTests returns the template data of a workflow's test summary.
+func commentTests(summary broker.TestSummary) *CommentTests {
+	tests := &CommentTests{
+		Total:           summary.Total,
+		Passed:          summary.Passed,
+		Failed:          summary.Failed,
+		Skipped:         summary.Skipped,
+		Flaky:           summary.Flaky,
+		MoreFailedTests: summary.MoreFailedTests,
+	}
+	for i, failedTest := range summary.FailedTests {
+		if i >= maxListedFailedTests {
+			tests.MoreFailedTests += len(summary.FailedTests) - maxListedFailedTests
+			break
+		}
+		tests.FailedTests = append(tests.FailedTests, CommentFailedTest{
+			Name:    failedTest.Name,
+			Message: singleLine(failedTest.Message),
+			Flaky:   failedTest.Flaky,
+		})
+	}
+	return tests
+}
+
+// singleLine returns text on a single line, so that it can be listed in a comment.
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// renderComment executes the comment template of the settings on data. A template that fails falls back to
+// defaultCommentTemplate, so that the results are still reported.
+func (gas *GitHubActionsServer) renderComment(data CommentData, gitHubActionsSettings app.GitHubActionsSettings) string {
+	if len(gitHubActionsSettings.CommentTemplate) > 0 {
+		comment, err := executeCommentTemplate(gitHubActionsSettings.CommentTemplate, data)
+		if err == nil {
+			return comment
+		}
+		gas.App.Logger.Warn("could not render comment template, using the default one", "error", err.Error())
+	}
+	comment, _ := executeCommentTemplate(defaultCommentTemplate, data)
+	return comment
+}
+
+func executeCommentTemplate(text string, data CommentData) (string, error) {
+	commentTemplate, err := template.New("comment").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var comment strings.Builder
+	err = commentTemplate.Execute(&comment, data)
+	if err != nil {
+		return "", err
+	}
+	return comment.String(), nil
+}
+
+// loadCommentTemplate sets the node's comment template of the repo from CommentTemplatesDir, unless the repo
+// provides its own.
+func (gas *GitHubActionsServer) loadCommentTemplate(repoID string, gitHubActionsSettings *app.GitHubActionsSettings) {
+	if gitHubActionsSettings == nil || len(gitHubActionsSettings.CommentTemplate) > 0 ||
+		len(gas.App.Config.CommentTemplatesDir) == 0 {
+		return
+	}
+	for _, name := range []string{strings.TrimPrefix(repoID, "rad:"), defaultCommentTemplateName} {
+		content, err := os.ReadFile(filepath.Join(gas.App.Config.CommentTemplatesDir,
+			filepath.Base(name)+commentTemplateSuffix))
+		if err == nil {
+			gitHubActionsSettings.CommentTemplate = string(content)
+			return
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package serve
+
+import (
+	"context"
+	"path"
+	"radicle-github-actions-adapter/app"
+	"radicle-github-actions-adapter/app/broker"
+)
+
+// maxListedFailedTests bounds the failed tests of a workflow listed in the result comment and in the broker's
+// response details.
+const maxListedFailedTests int = 10
+
+// addTestSummaries adds to the details of each workflow the summary of the JUnit test reports in its artifacts
+// matching the repo's TestReportArtifacts. Failing to fetch a summary is only logged.
+func (gas *GitHubActionsServer) addTestSummaries(ctx context.Context,
+	gitHubActionsSettings *app.GitHubActionsSettings, resultResponse *broker.ResponseMessage) {
+	if len(gitHubActionsSettings.TestReportArtifacts) == 0 {
+		return
+	}
+	for i, workflowDetails := range resultResponse.ResultDetails {
+		for _, artifact := range workflowDetails.WorkflowArtifacts {
+			matched, err := path.Match(gitHubActionsSettings.TestReportArtifacts, artifact.Name)
+			if err != nil {
+				gas.App.Logger.Warn("invalid test report artifacts pattern", "pattern",
+					gitHubActionsSettings.TestReportArtifacts, "error", err.Error())
+				return
+			}
+			if !matched {
+				continue
+			}
+			summary, err := gas.GitHubActions.GetArtifactTestSummary(ctx, gitHubActionsSettings.GitHubUsername,
+				gitHubActionsSettings.GitHubRepo, artifact.Id)
+			if err != nil {
+				gas.App.Logger.Warn("could not get test summary of artifact", "artifact", artifact.Name, "error",
+					err.Error())
+				continue
+			}
+			resultResponse.ResultDetails[i].WorkflowTestSummary = addTestSummary(
+				resultResponse.ResultDetails[i].WorkflowTestSummary, *summary)
+		}
+	}
+}
+
+// addTestSummary returns the total of the broker's test summary, if any, and summary. Only the first
+// maxListedFailedTests failed tests are listed, the others are counted in MoreFailedTests.
+func addTestSummary(total *broker.TestSummary, summary app.TestSummary) *broker.TestSummary {
+	if total == nil {
+		total = &broker.TestSummary{}
+	}
+	total.Total += summary.Total
+	total.Passed += summary.Passed
+	total.Failed += summary.Failed
+	total.Skipped += summary.Skipped
+	total.Flaky += summary.Flaky
+	for _, failedTest := range summary.FailedTests {
+		if len(total.FailedTests) >= maxListedFailedTests {
+			total.MoreFailedTests++
+			continue
+		}
+		total.FailedTests = append(total.FailedTests, broker.FailedTest{
+			Name:    failedTest.Name,
+			Message: failedTest.Message,
+			Flaky:   failedTest.Flaky,
+		})
+	}
+	return total
+}
@@ -0,0 +1,295 @@
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"os"
+	"radicle-github-actions-adapter/app/jobs"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxListedJobs bounds the jobs listed by the job list page and API, newest first.
+const maxListedJobs int = 100
+
+// refreshSecs is the interval at which the pages of running jobs reload.
+const refreshSecs int = 10
+
+const pageHeader string = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+{{- if .Running}}
+<meta http-equiv="refresh" content="{{.RefreshSecs}}">
+{{- end}}
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+code { font-size: 0.9em; }
+</style>
+</head>
+<body>
+`
+
+const jobListTemplate string = pageHeader + `<h1>{{.Title}}</h1>
+{{- if not .Jobs}}
+<p>No jobs yet.</p>
+{{- else}}
+<table>
+<tr><th>Job</th><th>Repo</th><th>Commit</th><th>Patch or branch</th><th>Phase</th><th>Result</th><th>Created</th>` +
+	`<th>Updated</th></tr>
+{{- range .Jobs}}
+<tr><td><a href="/jobs/{{.ID}}">{{.ID}}</a></td><td><code>{{.Repo}}</code></td>` +
+	`<td><code>{{shortCommit .Commit}}</code></td>` +
+	`<td>{{if .PatchID}}patch <code>{{shortCommit .PatchID}}</code>{{else}}{{.Branch}}{{end}}</td>` +
+	`<td>{{.Phase}}</td><td>{{.Result}}</td><td>{{formatTime .CreatedAt}}</td><td>{{formatTime .UpdatedAt}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+<p><a href="/api/jobs">JSON</a></p>
+</body>
+</html>
+`
+
+const jobTemplate string = pageHeader + `<h1>{{.Title}}</h1>
+{{- with .Job}}
+<table>
+<tr><th>Repo</th><td><code>{{.Repo}}</code></td></tr>
+<tr><th>Commit</th><td><code>{{.Commit}}</code></td></tr>
+{{- with .Branch}}
+<tr><th>Branch</th><td>{{.}}</td></tr>
+{{- end}}
+{{- with .PatchID}}
+<tr><th>Patch</th><td><code>{{.}}</code></td></tr>
+{{- end}}
+{{- with .RevisionID}}
+<tr><th>Revision</th><td><code>{{.}}</code></td></tr>
+{{- end}}
+{{- with .GitHubRepo}}
+<tr><th>GitHub repo</th><td><a href="https://github.com/{{$.Job.GitHubUsername}}/{{.}}">` +
+	`{{$.Job.GitHubUsername}}/{{.}}</a></td></tr>
+{{- end}}
+<tr><th>Phase</th><td>{{.Phase}}</td></tr>
+{{- with .Result}}
+<tr><th>Result</th><td>{{.}}</td></tr>
+{{- end}}
+{{- with .Coverage}}
+<tr><th>Coverage</th><td>{{formatPercent .}}</td></tr>
+{{- end}}
+<tr><th>Created</th><td>{{formatTime .CreatedAt}}</td></tr>
+<tr><th>Updated</th><td>{{formatTime .UpdatedAt}}</td></tr>
+</table>
+<h2>Workflows</h2>
+{{- if not .Workflows}}
+<p>No workflows yet.</p>
+{{- else}}
+<table>
+<tr><th>Workflow</th><th>Run</th><th>Commit</th><th>Status</th></tr>
+{{- range .Workflows}}
+<tr><td>{{.Name}}</td><td>{{if .URL}}<a href="{{.URL}}">#{{.ID}}</a>{{else}}#{{.ID}}{{end}}</td>` +
+	`<td><code>{{shortCommit .Commit}}</code></td><td>{{.Status}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+<p><a href="/jobs">All jobs</a> · <a href="/api/jobs/{{.Job.ID}}">JSON</a></p>
+</body>
+</html>
+`
+
+var templateFuncs = template.FuncMap{
+	"formatTime": func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.UTC().Format("2006-01-02 15:04:05 UTC")
+	},
+	"formatPercent": func(percent *float64) string {
+		return strconv.FormatFloat(*percent, 'f', 2, 64) + "%"
+	},
+	"shortCommit": func(commit string) string {
+		if len(commit) > 7 {
+			return commit[:7]
+		}
+		return commit
+	},
+}
+
+var (
+	jobListPage = template.Must(template.New("jobs").Funcs(templateFuncs).Parse(jobListTemplate))
+	jobPage     = template.Must(template.New("job").Funcs(templateFuncs).Parse(jobTemplate))
+)
+
+// StatusServer serves the state of the adapter's jobs: HTML pages at /jobs and /jobs/<RUN-UUID>, and the same as
+// JSON at /api/jobs and /api/jobs/<RUN-UUID>. The metrics written by the adapter processes are served at /metrics.
+type StatusServer struct {
+	logger          *slog.Logger
+	jobStore        jobs.Store
+	metricsTextfile string
+	mux             *http.ServeMux
+}
+
+type jobListData struct {
+	Title       string
+	Running     bool
+	RefreshSecs int
+	Jobs        []jobs.Job
+}
+
+type jobData struct {
+	Title       string
+	Running     bool
+	RefreshSecs int
+	Job         jobs.Job
+}
+
+// NewStatusServer returns a StatusServer which serves the jobs of jobStore and the metrics of metricsTextfile, if
+// any.
+func NewStatusServer(jobStore jobs.Store, metricsTextfile string, logger *slog.Logger) *StatusServer {
+	ss := &StatusServer{
+		logger:          logger,
+		jobStore:        jobStore,
+		metricsTextfile: metricsTextfile,
+		mux:             http.NewServeMux(),
+	}
+	ss.mux.HandleFunc("/", ss.handleJobList)
+	// Registered on its own, otherwise /jobs is redirected to the job page /jobs/ without a job ID.
+	ss.mux.HandleFunc("/jobs", ss.handleJobList)
+	ss.mux.HandleFunc("/jobs/", ss.handleJob)
+	ss.mux.HandleFunc("/api/jobs", ss.handleAPIJobList)
+	ss.mux.HandleFunc("/api/jobs/", ss.handleAPIJob)
+	ss.mux.HandleFunc("/metrics", ss.handleMetrics)
+	return ss
+}
+
+// ServeHTTP implements http.Handler.
+func (ss *StatusServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ss.mux.ServeHTTP(w, r)
+}
+
+func (ss *StatusServer) handleJobList(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" && r.URL.Path != "/jobs" {
+		http.NotFound(w, r)
+		return
+	}
+	listedJobs, err := ss.listJobs(r)
+	if err != nil {
+		http.Error(w, "could not list jobs", http.StatusInternalServerError)
+		return
+	}
+	data := jobListData{Title: "Radicle GitHub Actions adapter jobs", RefreshSecs: refreshSecs, Jobs: listedJobs}
+	data.Running = slices.ContainsFunc(listedJobs, isRunning)
+	ss.render(w, jobListPage, data)
+}
+
+func (ss *StatusServer) handleJob(w http.ResponseWriter, r *http.Request) {
+	job, status := ss.getJob(r, "/jobs/")
+	if job == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	ss.render(w, jobPage, jobData{Title: "Job " + job.ID, Running: isRunning(*job), RefreshSecs: refreshSecs,
+		Job: *job})
+}
+
+func (ss *StatusServer) handleAPIJobList(w http.ResponseWriter, r *http.Request) {
+	listedJobs, err := ss.listJobs(r)
+	if err != nil {
+		ss.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not list jobs"})
+		return
+	}
+	if listedJobs == nil {
+		listedJobs = []jobs.Job{}
+	}
+	ss.writeJSON(w, http.StatusOK, listedJobs)
+}
+
+func (ss *StatusServer) handleAPIJob(w http.ResponseWriter, r *http.Request) {
+	job, status := ss.getJob(r, "/api/jobs/")
+	if job == nil {
+		ss.writeJSON(w, status, map[string]string{"error": http.StatusText(status)})
+		return
+	}
+	ss.writeJSON(w, http.StatusOK, job)
+}
+
+// handleMetrics serves the metrics textfile written by the adapter processes, empty until the first one finished.
+func (ss *StatusServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if len(ss.metricsTextfile) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	content, err := os.ReadFile(ss.metricsTextfile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		ss.logger.Error("could not read metrics textfile", "path", ss.metricsTextfile, "error", err.Error())
+		http.Error(w, "could not read metrics", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, err = w.Write(content)
+	if err != nil {
+		ss.logger.Error("could not write metrics response", "error", err.Error())
+	}
+}
+
+// listJobs returns the latest jobs first, up to maxListedJobs.
+func (ss *StatusServer) listJobs(r *http.Request) ([]jobs.Job, error) {
+	storedJobs, err := ss.jobStore.List(r.Context())
+	if err != nil {
+		ss.logger.Error("could not list jobs", "error", err.Error())
+		return nil, err
+	}
+	slices.SortStableFunc(storedJobs, func(a, b jobs.Job) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	if len(storedJobs) > maxListedJobs {
+		storedJobs = storedJobs[:maxListedJobs]
+	}
+	return storedJobs, nil
+}
+
+// getJob returns the job whose ID follows prefix in the request path, or nil and the HTTP status to respond with.
+func (ss *StatusServer) getJob(r *http.Request, prefix string) (*jobs.Job, int) {
+	id := strings.TrimPrefix(r.URL.Path, prefix)
+	if len(id) == 0 || strings.Contains(id, "/") {
+		return nil, http.StatusNotFound
+	}
+	job, err := ss.jobStore.Get(r.Context(), id)
+	if errors.Is(err, jobs.ErrJobNotFound) {
+		return nil, http.StatusNotFound
+	}
+	if err != nil {
+		ss.logger.Error("could not get job", "id", id, "error", err.Error())
+		return nil, http.StatusInternalServerError
+	}
+	return job, http.StatusOK
+}
+
+func (ss *StatusServer) render(w http.ResponseWriter, page *template.Template, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := page.Execute(w, data)
+	if err != nil {
+		ss.logger.Error("could not render status page", "page", page.Name(), "error", err.Error())
+	}
+}
+
+func (ss *StatusServer) writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(value)
+	if err != nil {
+		ss.logger.Error("could not write status response", "error", err.Error())
+	}
+}
+
+// isRunning reports whether the job has not finished yet, so that its page is refreshed.
+func isRunning(job jobs.Job) bool {
+	return job.Phase == jobs.JobPhaseTriggered || job.Phase == jobs.JobPhaseWaiting
+}
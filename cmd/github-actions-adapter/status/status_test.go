@@ -0,0 +1,255 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"radicle-github-actions-adapter/app/jobs"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type MockJobStore struct {
+	jobs    map[string]jobs.Job
+	listErr error
+}
+
+func (s *MockJobStore) Save(ctx context.Context, job jobs.Job) error {
+	s.jobs[job.ID] = job
+	return nil
+}
+
+func (s *MockJobStore) Get(ctx context.Context, id string) (*jobs.Job, error) {
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, jobs.ErrJobNotFound
+	}
+	return &job, nil
+}
+
+func (s *MockJobStore) List(ctx context.Context) ([]jobs.Job, error) {
+	var result []jobs.Job
+	for _, job := range s.jobs {
+		result = append(result, job)
+	}
+	return result, s.listErr
+}
+
+func (s *MockJobStore) Delete(ctx context.Context, id string) error {
+	delete(s.jobs, id)
+	return nil
+}
+
+func TestStatusServer(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	coverage := 81.25
+	jobStore := &MockJobStore{jobs: map[string]jobs.Job{
+		"push-job": {ID: "push-job", Repo: "rad:z123", Commit: "0123456789abcdef", Branch: "main",
+			Phase: jobs.JobPhaseFinished, Result: "success", Coverage: &coverage, CreatedAt: now,
+			UpdatedAt: now.Add(5 * time.Minute)},
+		"patch-job": {ID: "patch-job", Repo: "rad:z123", Commit: "fedcba9876543210", PatchID: "abcdef0123456789",
+			RevisionID: "rev", GitHubUsername: "user", GitHubRepo: "repo", Phase: jobs.JobPhaseWaiting,
+			Workflows: []jobs.JobWorkflow{
+				{ID: "10", Name: "build", Commit: "fedcba9876543210", Status: "in_progress",
+					URL: "https://github.com/user/repo/actions/runs/10"},
+			},
+			CreatedAt: now.Add(time.Hour), UpdatedAt: now.Add(time.Hour)},
+	}}
+	server := httptest.NewServer(NewStatusServer(jobStore, "",
+		slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{}))))
+	defer server.Close()
+	cases := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedType   string
+		expected       []string
+		unexpected     []string
+	}{
+		{
+			name:           "job list page lists the latest jobs first and refreshes while jobs run",
+			path:           "/",
+			expectedStatus: http.StatusOK,
+			expectedType:   "text/html; charset=utf-8",
+			expected: []string{
+				`<meta http-equiv="refresh" content="10">`,
+				`<a href="/jobs/patch-job">patch-job</a></td><td><code>rad:z123</code></td><td><code>fedcba9</code>` +
+					`</td><td>patch <code>abcdef0</code></td><td>waiting</td><td></td>` +
+					`<td>2024-05-01 11:00:00 UTC</td>`,
+				`<td>main</td><td>finished</td><td>success</td><td>2024-05-01 10:00:00 UTC</td>` +
+					`<td>2024-05-01 10:05:00 UTC</td>`,
+			},
+		},
+		{
+			name:           "job list page is also served at the all jobs link of the job pages",
+			path:           "/jobs",
+			expectedStatus: http.StatusOK,
+			expectedType:   "text/html; charset=utf-8",
+			expected: []string{
+				`<a href="/jobs/patch-job">patch-job</a>`,
+				`<a href="/jobs/push-job">push-job</a>`,
+			},
+		},
+		{
+			name:           "job page shows the workflows of a running job",
+			path:           "/jobs/patch-job",
+			expectedStatus: http.StatusOK,
+			expectedType:   "text/html; charset=utf-8",
+			expected: []string{
+				`<meta http-equiv="refresh" content="10">`,
+				`<tr><th>Patch</th><td><code>abcdef0123456789</code></td></tr>`,
+				`<a href="https://github.com/user/repo">user/repo</a>`,
+				`<tr><td>build</td><td><a href="https://github.com/user/repo/actions/runs/10">#10</a></td>` +
+					`<td><code>fedcba9</code></td><td>in_progress</td></tr>`,
+			},
+		},
+		{
+			name:           "job page of a finished job does not refresh",
+			path:           "/jobs/push-job",
+			expectedStatus: http.StatusOK,
+			expectedType:   "text/html; charset=utf-8",
+			expected: []string{
+				`<a href="/jobs">All jobs</a>`,
+				`<tr><th>Branch</th><td>main</td></tr>`,
+				`<tr><th>Coverage</th><td>81.25%</td></tr>`,
+				`<p>No workflows yet.</p>`,
+			},
+			unexpected: []string{`http-equiv="refresh"`},
+		},
+		{
+			name:           "job page of an unknown job is not found",
+			path:           "/jobs/unknown",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "job API returns the job",
+			path:           "/api/jobs/push-job",
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/json",
+			expected:       []string{`"id":"push-job"`, `"coverage":81.25`},
+		},
+		{
+			name:           "job API of an unknown job is not found",
+			path:           "/api/jobs/unknown",
+			expectedStatus: http.StatusNotFound,
+			expectedType:   "application/json",
+			expected:       []string{`{"error":"Not Found"}`},
+		},
+		{
+			name:           "metrics are not found without a metrics textfile",
+			path:           "/metrics",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "other paths are not found",
+			path:           "/other",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := http.Get(server.URL + tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tc.expectedStatus {
+				t.Fatalf("GET %s got status %d, want %d", tc.path, resp.StatusCode, tc.expectedStatus)
+			}
+			if len(tc.expectedType) > 0 && resp.Header.Get("Content-Type") != tc.expectedType {
+				t.Errorf("GET %s got content type %s, want %s", tc.path, resp.Header.Get("Content-Type"),
+					tc.expectedType)
+			}
+			for _, expected := range tc.expected {
+				if !strings.Contains(string(body), expected) {
+					t.Errorf("GET %s got %q, want it to contain %q", tc.path, body, expected)
+				}
+			}
+			for _, unexpected := range tc.unexpected {
+				if strings.Contains(string(body), unexpected) {
+					t.Errorf("GET %s got %q, want it not to contain %q", tc.path, body, unexpected)
+				}
+			}
+		})
+	}
+}
+
+func TestStatusServer_APIJobList(t *testing.T) {
+	now := time.Now()
+	jobStore := &MockJobStore{jobs: map[string]jobs.Job{}}
+	for i := 0; i < maxListedJobs+1; i++ {
+		id := "job-" + strconv.Itoa(i)
+		jobStore.jobs[id] = jobs.Job{ID: id, CreatedAt: now.Add(time.Duration(i) * time.Second)}
+	}
+	server := httptest.NewServer(NewStatusServer(jobStore, "",
+		slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{}))))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var listedJobs []jobs.Job
+	err = json.NewDecoder(resp.Body).Decode(&listedJobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listedJobs) != maxListedJobs || listedJobs[0].ID != "job-100" || listedJobs[maxListedJobs-1].ID != "job-1" {
+		t.Errorf("GET /api/jobs got %d jobs from %s, want %d jobs from job-100 to job-1", len(listedJobs),
+			listedJobs[0].ID, maxListedJobs)
+	}
+
+	jobStore.listErr = errors.New("unreadable jobs directory")
+	resp, err = http.Get(server.URL + "/api/jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("GET /api/jobs got status %d, want %d", resp.StatusCode, http.StatusInternalServerError)
+	}
+}
+
+func TestStatusServer_Metrics(t *testing.T) {
+	metricsTextfile := filepath.Join(t.TempDir(), "adapter.prom")
+	server := httptest.NewServer(NewStatusServer(&MockJobStore{jobs: map[string]jobs.Job{}}, metricsTextfile,
+		slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{}))))
+	defer server.Close()
+	metrics := "# TYPE radicle_github_actions_adapter_jobs_total counter\n" +
+		"radicle_github_actions_adapter_jobs_total{event=\"push\",result=\"success\"} 3\n"
+
+	for _, want := range []string{"", metrics} {
+		resp, err := http.Get(server.URL + "/metrics")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK || string(body) != want {
+			t.Errorf("GET /metrics got status %d and %q, want %d and %q", resp.StatusCode, body, http.StatusOK,
+				want)
+		}
+		if resp.Header.Get("Content-Type") != "text/plain; version=0.0.4; charset=utf-8" {
+			t.Errorf("GET /metrics got content type %s", resp.Header.Get("Content-Type"))
+		}
+		if err := os.WriteFile(metricsTextfile, []byte(metrics), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
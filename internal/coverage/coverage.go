@@ -0,0 +1,167 @@
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var ErrUnknownFormat = errors.New("unknown coverage report format")
+
+// Report counts the covered and total lines of coverage reports, or statements for Go cover profiles.
+type Report struct {
+	Covered int
+	Total   int
+}
+
+// Percent returns the coverage of the report in percent, 0 when it has no lines.
+func (r Report) Percent() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(r.Covered) * 100 / float64(r.Total)
+}
+
+// Parse adds the lines of a Cobertura XML, LCOV or Go cover profile report to the report, detecting its format from
+// its content. It returns ErrUnknownFormat for other content.
+func (r *Report) Parse(content []byte) error {
+	content = bytes.TrimSpace(content)
+	switch {
+	case bytes.HasPrefix(content, []byte("mode:")):
+		return r.parseGoCoverProfile(content)
+	case bytes.HasPrefix(content, []byte("<")):
+		return r.parseCobertura(content)
+	case bytes.Contains(content, []byte("end_of_record")):
+		return r.parseLCOV(content)
+	}
+	return ErrUnknownFormat
+}
+
+// parseGoCoverProfile parses the blocks of a Go cover profile, name.go:line.column,line.column statements count.
+// Blocks listed more than once, e.g. in merged profiles, are covered if any of them is.
+func (r *Report) parseGoCoverProfile(content []byte) error {
+	type block struct {
+		statements int
+		covered    bool
+	}
+	blocks := map[string]block{}
+	var order []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Scan() // mode line
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return errors.New("invalid cover profile line: " + scanner.Text())
+		}
+		statements, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return err
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return err
+		}
+		existing, found := blocks[fields[0]]
+		if !found {
+			order = append(order, fields[0])
+		}
+		blocks[fields[0]] = block{statements: statements, covered: existing.covered || count > 0}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	for _, key := range order {
+		r.Total += blocks[key].statements
+		if blocks[key].covered {
+			r.Covered += blocks[key].statements
+		}
+	}
+	return nil
+}
+
+// parseCobertura uses the lines-valid and lines-covered of a Cobertura report, or counts its lines without them.
+func (r *Report) parseCobertura(content []byte) error {
+	var report struct {
+		XMLName      xml.Name `xml:"coverage"`
+		LinesValid   *int     `xml:"lines-valid,attr"`
+		LinesCovered *int     `xml:"lines-covered,attr"`
+		Packages     []struct {
+			Classes []struct {
+				Lines []struct {
+					Hits int `xml:"hits,attr"`
+				} `xml:"lines>line"`
+			} `xml:"classes>class"`
+		} `xml:"packages>package"`
+	}
+	err := xml.Unmarshal(content, &report)
+	if err != nil {
+		return err
+	}
+	if report.LinesValid != nil && report.LinesCovered != nil {
+		r.Total += *report.LinesValid
+		r.Covered += *report.LinesCovered
+		return nil
+	}
+	for _, pkg := range report.Packages {
+		for _, class := range pkg.Classes {
+			for _, line := range class.Lines {
+				r.Total++
+				if line.Hits > 0 {
+					r.Covered++
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// parseLCOV uses the LF and LH summary of each record of an LCOV report, or counts its DA lines without them. The
+// records are only added to the report once the whole report is parsed.
+func (r *Report) parseLCOV(content []byte) error {
+	var report Report
+	var found, hit, lines, hitLines int
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		var err error
+		switch key {
+		case "LF":
+			found, err = strconv.Atoi(value)
+		case "LH":
+			hit, err = strconv.Atoi(value)
+		case "DA":
+			fields := strings.Split(value, ",")
+			if len(fields) < 2 {
+				return errors.New("invalid LCOV line: " + scanner.Text())
+			}
+			var hits int
+			hits, err = strconv.Atoi(fields[1])
+			lines++
+			if hits > 0 {
+				hitLines++
+			}
+		case "end_of_record":
+			if found == 0 {
+				found, hit = lines, hitLines
+			}
+			report.Total += found
+			report.Covered += hit
+			found, hit, lines, hitLines = 0, 0, 0, 0
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	r.Total += report.Total
+	r.Covered += report.Covered
+	return nil
+}
@@ -0,0 +1,99 @@
+package coverage
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestReport_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		reports []string
+		want    Report
+		wantErr error
+	}{
+		{
+			name: "Parse counts the statements of a Go cover profile",
+			reports: []string{`mode: set
+example.com/pkg/a.go:3.10,5.2 2 1
+example.com/pkg/a.go:7.10,9.2 3 0
+example.com/pkg/b.go:3.10,5.2 5 0
+example.com/pkg/b.go:3.10,5.2 5 1
+`},
+			want: Report{Covered: 7, Total: 10},
+		},
+		{
+			name: "Parse uses the line totals of a Cobertura report",
+			reports: []string{`<?xml version="1.0" ?>
+<coverage line-rate="0.8" lines-covered="8" lines-valid="10"><packages/></coverage>`},
+			want: Report{Covered: 8, Total: 10},
+		},
+		{
+			name: "Parse counts the lines of a Cobertura report without totals",
+			reports: []string{`<coverage line-rate="0.5"><packages><package><classes><class><lines>
+<line number="1" hits="3"/><line number="2" hits="0"/></lines></class></classes></package></packages></coverage>`},
+			want: Report{Covered: 1, Total: 2},
+		},
+		{
+			name: "Parse uses the line summaries of an LCOV report or counts its lines",
+			reports: []string{`TN:
+SF:src/a.js
+DA:1,1
+DA:2,0
+LF:2
+LH:1
+end_of_record
+SF:src/b.js
+DA:1,4
+DA:2,1
+DA:3,0
+end_of_record`},
+			want: Report{Covered: 3, Total: 5},
+		},
+		{
+			name:    "Parse adds the lines of several reports",
+			reports: []string{"mode: count\na.go:1.1,2.2 4 2\n", `<coverage lines-covered="1" lines-valid="4"/>`},
+			want:    Report{Covered: 5, Total: 8},
+		},
+		{
+			name: "Parse adds nothing of an LCOV report with an invalid line after a record",
+			reports: []string{"mode: count\na.go:1.1,2.2 4 2\n",
+				"SF:src/a.js\nLF:2\nLH:1\nend_of_record\nSF:src/b.js\nLF:two\nend_of_record\n"},
+			want:    Report{Covered: 4, Total: 4},
+			wantErr: strconv.ErrSyntax,
+		},
+		{
+			name:    "Parse fails on other content",
+			reports: []string{"2 passed"},
+			wantErr: ErrUnknownFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Report
+			var err error
+			for _, report := range tt.reports {
+				err = got.Parse([]byte(report))
+				if err != nil {
+					break
+				}
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Parse() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReport_Percent(t *testing.T) {
+	if got := (Report{Covered: 1, Total: 8}).Percent(); got != 12.5 {
+		t.Errorf("Percent() got = %v, want 12.5", got)
+	}
+	if got := (Report{}).Percent(); got != 0 {
+		t.Errorf("Percent() got = %v, want 0", got)
+	}
+}
@@ -1,26 +1,38 @@
 package git
 
 import (
+	"context"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"log/slog"
+	"radicle-github-actions-adapter/app/metrics"
+	"time"
 )
 
 type Git struct {
-	logger *slog.Logger
+	logger  *slog.Logger
+	metrics metrics.Metrics
 }
 
-func NewGit(logger *slog.Logger) *Git {
+// NewGit returns a Git whose clone durations are recorded to metrics, if not nil.
+func NewGit(logger *slog.Logger, metrics metrics.Metrics) *Git {
 	return &Git{
-		logger: logger,
+		logger:  logger,
+		metrics: metrics,
 	}
 }
 
 // CloneRepoCommit clones a repo from url to repoPath and checkouts to commitHash.
-// It does not handle removing the created files.
-func (g *Git) CloneRepoCommit(url, commitHash, repoPath string) error {
-	repo, err := git.PlainClone(repoPath, false, &git.CloneOptions{
+// It does not handle removing the created files. Clone and fetch are aborted as soon as ctx is done.
+func (g *Git) CloneRepoCommit(ctx context.Context, url, commitHash, repoPath string) error {
+	if g.metrics != nil {
+		start := time.Now()
+		defer func() {
+			g.metrics.ObserveClone(time.Since(start))
+		}()
+	}
+	repo, err := git.PlainCloneContext(ctx, repoPath, false, &git.CloneOptions{
 		URL:               url,
 		SingleBranch:      false,
 		RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
@@ -35,7 +47,7 @@ func (g *Git) CloneRepoCommit(url, commitHash, repoPath string) error {
 		return err
 	}
 
-	err = repo.Fetch(&git.FetchOptions{
+	err = repo.FetchContext(ctx, &git.FetchOptions{
 		RefSpecs: []config.RefSpec{
 			"+refs/*:refs/*",
 		},
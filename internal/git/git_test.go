@@ -1,6 +1,7 @@
 package git
 
 import (
+	"context"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
@@ -11,10 +12,13 @@ import (
 )
 
 func TestGit_CloneRepoCommit(t *testing.T) {
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
 	type fields struct {
 		logger *slog.Logger
 	}
 	type args struct {
+		ctx        context.Context
 		url        string
 		commitHash string
 		repoPath   string
@@ -33,6 +37,7 @@ func TestGit_CloneRepoCommit(t *testing.T) {
 				logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
 			},
 			args: args{
+				ctx:        context.Background(),
 				url:        "file:///tmp/repo_name",
 				commitHash: "",
 				repoPath:   "/tmp/cloned_repo_name",
@@ -130,6 +135,7 @@ func TestGit_CloneRepoCommit(t *testing.T) {
 				logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
 			},
 			args: args{
+				ctx:        context.Background(),
 				url:        "file:///tmp/repo_name",
 				commitHash: "",
 				repoPath:   "/tmp/cloned_repo_name",
@@ -185,6 +191,7 @@ func TestGit_CloneRepoCommit(t *testing.T) {
 				logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
 			},
 			args: args{
+				ctx:        context.Background(),
 				url:        "file:///tmp/repo_name",
 				commitHash: "",
 				repoPath:   "/tmp/cloned_repo_name",
@@ -225,11 +232,61 @@ func TestGit_CloneRepoCommit(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "CloneRepoCommit fails when context is cancelled",
+			fields: fields{
+				logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+			},
+			args: args{
+				ctx:        cancelledCtx,
+				url:        "file:///tmp/repo_name",
+				commitHash: "",
+				repoPath:   "/tmp/cloned_repo_name",
+			},
+			prepareFunc: func() (string, error) {
+				repoPath := "/tmp/repo_name"
+				err := os.MkdirAll(repoPath, 0777)
+				if err != nil {
+					return "", err
+				}
+				repo, err := git.PlainInit(repoPath, false)
+				if err != nil {
+					return "", err
+				}
+				w, err := repo.Worktree()
+				if err != nil {
+					return "", err
+				}
+				_, err = os.Create(repoPath + "/Readme.md")
+				if err != nil {
+					return "", err
+				}
+				_, err = w.Add(".")
+				if err != nil {
+					return "", err
+				}
+				commitHash, err := w.Commit("initial commit", &git.CommitOptions{
+					Author: &object.Signature{
+						Name:  "John Doe",
+						Email: "john@doe.org",
+						When:  time.Now(),
+					},
+				})
+				return commitHash.String(), err
+			},
+			cleanupFunc: func() {
+				os.RemoveAll("/tmp/repo_name")
+				os.RemoveAll("/tmp/cloned_repo_name")
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			mockMetrics := &MockMetrics{}
 			g := &Git{
-				logger: tt.fields.logger,
+				logger:  tt.fields.logger,
+				metrics: mockMetrics,
 			}
 			defer tt.cleanupFunc()
 			commitHash, err := tt.prepareFunc()
@@ -238,9 +295,12 @@ func TestGit_CloneRepoCommit(t *testing.T) {
 				return
 			}
 			tt.args.commitHash = commitHash
-			if err := g.CloneRepoCommit(tt.args.url, tt.args.commitHash, tt.args.repoPath); (err != nil) != tt.wantErr {
+			if err := g.CloneRepoCommit(tt.args.ctx, tt.args.url, tt.args.commitHash, tt.args.repoPath); (err != nil) != tt.wantErr {
 				t.Errorf("CloneRepoCommit() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if mockMetrics.clones != 1 {
+				t.Errorf("CloneRepoCommit() observed %d clones, want 1", mockMetrics.clones)
+			}
 			if !tt.wantErr {
 				if _, err := os.Stat(tt.args.repoPath + "/file1"); err == nil {
 					t.Errorf("CloneRepoCommit() expected not to find file ./file1 but found it")
@@ -255,3 +315,21 @@ func TestGit_CloneRepoCommit(t *testing.T) {
 		})
 	}
 }
+
+type MockMetrics struct {
+	clones int
+}
+
+func (m *MockMetrics) ObserveJob(event, result string, duration time.Duration) {}
+
+func (m *MockMetrics) ObserveTimeToFirstRun(duration time.Duration) {}
+
+func (m *MockMetrics) ObserveGitHubAPICall(endpoint string, statusCode int) {}
+
+func (m *MockMetrics) SetGitHubRateLimitRemaining(remaining int) {}
+
+func (m *MockMetrics) ObserveRadicleAction(action string, err error) {}
+
+func (m *MockMetrics) ObserveClone(duration time.Duration) {
+	m.clones++
+}
@@ -1,12 +1,24 @@
 package github
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
 	"fmt"
 	"github.com/google/go-github/v57/github"
+	"io"
 	"log/slog"
+	"net/http"
+	"net/url"
 	"radicle-github-actions-adapter/app/githubops"
+	"radicle-github-actions-adapter/app/metrics"
+	"radicle-github-actions-adapter/internal/coverage"
+	"radicle-github-actions-adapter/internal/junit"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 )
 
 type GitHub struct {
@@ -14,6 +26,27 @@ type GitHub struct {
 	pat     string
 	repos   RepositoriesService
 	actions ActionsService
+	checks  ChecksService
+	// client downloads the artifacts from the URLs returned by the API.
+	client httpClient
+	// billableTime enables fetching the billable time of the completed runs.
+	billableTime bool
+	// cacheMu guards the details of earlier and completed run attempts, which no longer change, so that they are
+	// fetched once rather than on every poll.
+	cacheMu    sync.Mutex
+	attempts   map[runAttemptKey]githubops.WorkflowAttempt
+	artifacts  map[runAttemptKey][]githubops.WorkflowArtifact
+	billableMS map[runAttemptKey]int64
+}
+
+// runAttemptKey identifies an attempt of a workflow run.
+type runAttemptKey struct {
+	runID   int64
+	attempt int
+}
+
+type httpClient interface {
+	Do(req *http.Request) (*http.Response, error)
 }
 
 type RepositoriesService interface {
@@ -26,19 +59,41 @@ type ActionsService interface {
 		opts *github.ListWorkflowRunsOptions) (*github.WorkflowRuns, *github.Response, error)
 	ListWorkflowRunArtifacts(ctx context.Context, owner, repo string, runID int64,
 		opts *github.ListOptions) (*github.ArtifactList, *github.Response, error)
+	GetWorkflowRunAttempt(ctx context.Context, owner, repo string, runID int64, attemptNumber int,
+		opts *github.WorkflowRunAttemptOptions) (*github.WorkflowRun, *github.Response, error)
+	GetWorkflowRunUsageByID(ctx context.Context, owner, repo string, runID int64) (*github.WorkflowRunUsage,
+		*github.Response, error)
+	ListWorkflowJobs(ctx context.Context, owner, repo string, runID int64,
+		opts *github.ListWorkflowJobsOptions) (*github.Jobs, *github.Response, error)
+	DownloadArtifact(ctx context.Context, owner, repo string, artifactID int64, maxRedirects int) (*url.URL,
+		*github.Response, error)
+}
+
+type ChecksService interface {
+	ListCheckRunAnnotations(ctx context.Context, owner, repo string, checkRunID int64,
+		opts *github.ListOptions) ([]*github.CheckRunAnnotation, *github.Response, error)
 }
 
-func NewGitHub(pat string, logger *slog.Logger) *GitHub {
+// NewGitHub returns a GitHub client authenticated with pat, if any. Its API calls are recorded to metrics, if not nil.
+// The billable time of the workflow runs is only fetched with billableTime.
+func NewGitHub(pat string, billableTime bool, logger *slog.Logger, metrics metrics.Metrics) *GitHub {
+	var apiClient *http.Client
+	if metrics != nil {
+		apiClient = &http.Client{Transport: &metricsTransport{metrics: metrics, next: http.DefaultTransport}}
+	}
 	ghClient := &github.Client{}
 	if len(pat) == 0 {
-		ghClient = github.NewClient(nil)
+		ghClient = github.NewClient(apiClient)
 	} else {
-		ghClient = github.NewClient(nil).WithAuthToken(pat)
+		ghClient = github.NewClient(apiClient).WithAuthToken(pat)
 	}
 	return &GitHub{
-		logger:  logger,
-		repos:   ghClient.Repositories,
-		actions: ghClient.Actions,
+		logger:       logger,
+		repos:        ghClient.Repositories,
+		actions:      ghClient.Actions,
+		checks:       ghClient.Checks,
+		client:       http.DefaultClient,
+		billableTime: billableTime,
 	}
 }
 
@@ -53,66 +108,469 @@ func (gh *GitHub) CheckRepoCommit(ctx context.Context, user, repo, commit string
 }
 
 // GetRepoCommitWorkflows returns all the available workflows of the specified repo and commit.
+// Runs are grouped by workflow and only the latest run of each workflow is returned, with any superseded run or
+// earlier attempt kept as its previous attempts. Runs not matching filter are ignored.
 // If no workflows exist it does not return any error.
-func (gh *GitHub) GetRepoCommitWorkflows(ctx context.Context, user, repo, commit string) ([]githubops.WorkflowResult, error) {
+func (gh *GitHub) GetRepoCommitWorkflows(ctx context.Context, user, repo, commit string,
+	filter githubops.WorkflowRunsFilter) ([]githubops.WorkflowResult, error) {
 	workflowsListOptions := github.ListOptions{
 		Page:    0,
 		PerPage: 30, //default 30, range [0-100]
 	}
-	var result []githubops.WorkflowResult
+	var runs []*github.WorkflowRun
 	for {
-		runs, workflowsResp, err := gh.actions.ListRepositoryWorkflowRuns(ctx, user, repo,
+		workflowRuns, workflowsResp, err := gh.actions.ListRepositoryWorkflowRuns(ctx, user, repo,
 			&github.ListWorkflowRunsOptions{
 				HeadSHA:     commit,
+				Branch:      filter.HeadBranch,
 				ListOptions: workflowsListOptions,
 			})
 		if err != nil {
 			gh.logger.Error("failed to get repo commit", "error", err.Error())
 			return nil, err
 		}
-		for _, run := range runs.WorkflowRuns {
-			artifactsListOptions := github.ListOptions{
-				Page:    0,
-				PerPage: 30, //default 30, range [0-100]
+		for _, run := range workflowRuns.WorkflowRuns {
+			if len(filter.Events) > 0 && !slices.Contains(filter.Events, run.GetEvent()) {
+				gh.logger.Debug("skipping workflow run of filtered out event", "run_id", run.GetID(), "event",
+					run.GetEvent())
+				continue
+			}
+			runs = append(runs, run)
+		}
+		if workflowsResp.NextPage == 0 {
+			break
+		}
+		workflowsListOptions.Page = workflowsResp.NextPage
+	}
+
+	var result []githubops.WorkflowResult
+	for _, workflowRuns := range groupWorkflowRuns(runs) {
+		run := workflowRuns[0]
+		var previousAttempts []githubops.WorkflowAttempt
+		for attempt := run.GetRunAttempt() - 1; attempt > 0; attempt-- {
+			previousAttempt, err := gh.getWorkflowRunAttempt(ctx, user, repo, run.GetID(), attempt)
+			if err != nil {
+				gh.logger.Warn("could not fetch workflow run attempt", "run_id", run.GetID(), "attempt", attempt,
+					"error", err.Error())
+				continue
 			}
-			var workflowArtifacts []github.Artifact
-			for {
-				artifacts, artifactsResp, err := gh.actions.ListWorkflowRunArtifacts(ctx, user, repo, run.GetID(),
-					&artifactsListOptions)
-				if err != nil {
-					gh.logger.Error("could not fetch workflow artifacts", "error", err.Error())
-					break
-				}
-				for _, artifact := range artifacts.Artifacts {
-					workflowArtifacts = append(workflowArtifacts, *artifact)
-				}
-				if artifactsResp.NextPage == 0 {
-					break
-				}
+			previousAttempts = append(previousAttempts, previousAttempt)
+		}
+		for _, supersededRun := range workflowRuns[1:] {
+			previousAttempts = append(previousAttempts, workflowAttempt(user, repo, supersededRun))
+		}
+		result = append(result, githubops.WorkflowResult{
+			WorkflowID:       strconv.FormatInt(run.GetID(), 10),
+			WorkflowName:     run.GetName(),
+			Status:           githubops.WorkflowStatus(run.GetStatus()),
+			Result:           githubops.WorkflowConclusion(run.GetConclusion()),
+			Event:            run.GetEvent(),
+			HeadBranch:       run.GetHeadBranch(),
+			RunNumber:        run.GetRunNumber(),
+			RunAttempt:       run.GetRunAttempt(),
+			CreatedAt:        run.GetCreatedAt().Time,
+			StartedAt:        run.GetRunStartedAt().Time,
+			UpdatedAt:        run.GetUpdatedAt().Time,
+			HTMLURL:          run.GetHTMLURL(),
+			BillableMS:       gh.getWorkflowRunBillableMS(ctx, user, repo, run),
+			Artifacts:        gh.getCompletedRunArtifacts(ctx, user, repo, run),
+			PreviousAttempts: previousAttempts,
+		})
+	}
+	return result, nil
+}
+
+// GetWorkflowRunAnnotations returns the annotations of the check runs of the latest attempt of a workflow run, one
+// check run per job. At most 100 annotations are returned per job.
+func (gh *GitHub) GetWorkflowRunAnnotations(ctx context.Context, user, repo,
+	runID string) ([]githubops.WorkflowAnnotation, error) {
+	id, err := strconv.ParseInt(runID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workflow run ID %q: %w", runID, err)
+	}
+	jobsListOptions := github.ListWorkflowJobsOptions{
+		ListOptions: github.ListOptions{
+			Page:    0,
+			PerPage: 30, //default 30, range [0-100]
+		},
+	}
+	var result []githubops.WorkflowAnnotation
+	for {
+		jobs, jobsResp, err := gh.actions.ListWorkflowJobs(ctx, user, repo, id, &jobsListOptions)
+		if err != nil {
+			gh.logger.Error("could not fetch workflow jobs", "run_id", runID, "error", err.Error())
+			return nil, err
+		}
+		for _, job := range jobs.Jobs {
+			// The check run of a job has the same ID as the job.
+			annotations, _, err := gh.checks.ListCheckRunAnnotations(ctx, user, repo, job.GetID(),
+				&github.ListOptions{PerPage: 100})
+			if err != nil {
+				gh.logger.Error("could not fetch check run annotations", "job_id", job.GetID(), "error", err.Error())
+				return nil, err
 			}
-			var resultArtifacts []githubops.WorkflowArtifact
-			for _, workflowArtifact := range workflowArtifacts {
-				resultArtifacts = append(resultArtifacts, githubops.WorkflowArtifact{
-					Id:   strconv.FormatInt(workflowArtifact.GetID(), 10),
-					Name: workflowArtifact.GetName(),
-					Url: fmt.Sprintf("https://github.com/%s/%s/actions/runs/%d/artifacts/%d",
-						user, repo, run.GetID(), workflowArtifact.GetID()),
-					ApiUrl: workflowArtifact.GetURL(),
+			for _, annotation := range annotations {
+				result = append(result, githubops.WorkflowAnnotation{
+					Path:      annotation.GetPath(),
+					StartLine: annotation.GetStartLine(),
+					EndLine:   annotation.GetEndLine(),
+					Level:     annotation.GetAnnotationLevel(),
+					Title:     annotation.GetTitle(),
+					Message:   annotation.GetMessage(),
 				})
 			}
-			result = append(result, githubops.WorkflowResult{
-				WorkflowID:   strconv.FormatInt(run.GetID(), 10),
-				WorkflowName: run.GetName(),
-				Status:       run.GetStatus(),
-				Result:       run.GetConclusion(),
-				Artifacts:    resultArtifacts,
-			})
-			artifactsListOptions.Page++
 		}
-		if workflowsResp.NextPage == 0 {
+		if jobsResp.NextPage == 0 {
 			break
 		}
-		workflowsListOptions.Page = workflowsResp.NextPage
+		jobsListOptions.Page = jobsResp.NextPage
 	}
 	return result, nil
 }
+
+// maxArtifactSize bounds the size of the downloaded test report and coverage artifacts.
+const maxArtifactSize int64 = 32 << 20
+
+// maxReportSize bounds the decompressed size of each file of a test report or coverage artifact and maxReportsSize
+// the one of all its files, so that a small archive cannot expand without bounds. Larger files are skipped.
+const (
+	maxReportSize  int64 = 32 << 20
+	maxReportsSize int64 = 128 << 20
+)
+
+// GetArtifactTestSummary downloads an artifact and summarizes the JUnit XML test reports in it. Files that are not
+// JUnit reports are ignored.
+func (gh *GitHub) GetArtifactTestSummary(ctx context.Context, user, repo,
+	artifactID string) (*githubops.TestSummary, error) {
+	archive, err := gh.downloadArtifact(ctx, user, repo, artifactID)
+	if err != nil {
+		return nil, err
+	}
+	var report junit.Report
+	budget := maxReportsSize
+	for _, file := range archive.File {
+		if !strings.HasSuffix(strings.ToLower(file.Name), ".xml") {
+			continue
+		}
+		var size int64
+		size, err = parseTestReport(file, &report, min(maxReportSize, budget))
+		budget -= size
+		if err != nil {
+			gh.logger.Debug("skipping artifact file that is not a JUnit report", "artifact_id", artifactID, "file",
+				file.Name, "error", err.Error())
+		}
+	}
+	summary := &githubops.TestSummary{
+		Total:   report.Total,
+		Passed:  report.Passed,
+		Failed:  report.Failed,
+		Skipped: report.Skipped,
+		Flaky:   report.Flaky,
+	}
+	for _, failure := range report.Failures {
+		summary.FailedTests = append(summary.FailedTests, githubops.FailedTest{
+			Name:    failure.Name,
+			Message: failure.Message,
+			Flaky:   failure.Flaky,
+		})
+	}
+	return summary, nil
+}
+
+// GetArtifactCoverage downloads an artifact and adds up the Cobertura, LCOV and Go cover profile reports in it.
+// Files that are not coverage reports are ignored, an artifact without any is an error.
+func (gh *GitHub) GetArtifactCoverage(ctx context.Context, user, repo,
+	artifactID string) (*githubops.Coverage, error) {
+	archive, err := gh.downloadArtifact(ctx, user, repo, artifactID)
+	if err != nil {
+		return nil, err
+	}
+	var report coverage.Report
+	reports := 0
+	budget := maxReportsSize
+	for _, file := range archive.File {
+		var size int64
+		size, err = parseCoverageReport(file, &report, min(maxReportSize, budget))
+		budget -= size
+		if err != nil {
+			gh.logger.Debug("skipping artifact file that is not a coverage report", "artifact_id", artifactID, "file",
+				file.Name, "error", err.Error())
+			continue
+		}
+		reports++
+	}
+	if reports == 0 {
+		return nil, fmt.Errorf("artifact %s has no coverage report", artifactID)
+	}
+	return &githubops.Coverage{Covered: report.Covered, Total: report.Total}, nil
+}
+
+// downloadArtifact downloads the zip archive of an artifact.
+func (gh *GitHub) downloadArtifact(ctx context.Context, user, repo, artifactID string) (*zip.Reader, error) {
+	id, err := strconv.ParseInt(artifactID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid artifact ID %q: %w", artifactID, err)
+	}
+	downloadURL, _, err := gh.actions.DownloadArtifact(ctx, user, repo, id, 1)
+	if err != nil {
+		gh.logger.Error("could not get artifact download URL", "artifact_id", artifactID, "error", err.Error())
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := gh.client.Do(req)
+	if err != nil {
+		gh.logger.Error("could not download artifact", "artifact_id", artifactID, "error", err.Error())
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not download artifact %s: %s", artifactID, resp.Status)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxArtifactSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > maxArtifactSize {
+		return nil, fmt.Errorf("artifact %s is larger than %d bytes", artifactID, maxArtifactSize)
+	}
+	return zip.NewReader(bytes.NewReader(content), int64(len(content)))
+}
+
+// parseTestReport decompresses a file of up to maxSize bytes and adds it to the test report. It returns the number of
+// bytes decompressed.
+func parseTestReport(file *zip.File, report *junit.Report, maxSize int64) (int64, error) {
+	content, err := readArtifactFile(file, maxSize)
+	if err != nil {
+		return int64(len(content)), err
+	}
+	return int64(len(content)), report.Parse(bytes.NewReader(content))
+}
+
+// parseCoverageReport decompresses a file of up to maxSize bytes and adds it to the coverage report. It returns the
+// number of bytes decompressed.
+func parseCoverageReport(file *zip.File, report *coverage.Report, maxSize int64) (int64, error) {
+	content, err := readArtifactFile(file, maxSize)
+	if err != nil {
+		return int64(len(content)), err
+	}
+	return int64(len(content)), report.Parse(content)
+}
+
+// readArtifactFile decompresses a file of an artifact. Files larger than maxSize bytes are an error, returned with
+// the bytes decompressed until then.
+func readArtifactFile(file *zip.File, maxSize int64) ([]byte, error) {
+	if file.UncompressedSize64 > uint64(max(maxSize, 0)) {
+		return nil, fmt.Errorf("file is larger than %d bytes", maxSize)
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if err != nil {
+		return content, err
+	}
+	if int64(len(content)) > maxSize {
+		return content, fmt.Errorf("file is larger than %d bytes", maxSize)
+	}
+	return content, nil
+}
+
+// getWorkflowRunAttempt returns an earlier attempt of a workflow run, fetched once.
+func (gh *GitHub) getWorkflowRunAttempt(ctx context.Context, user, repo string, runID int64,
+	attempt int) (githubops.WorkflowAttempt, error) {
+	key := runAttemptKey{runID: runID, attempt: attempt}
+	gh.cacheMu.Lock()
+	previousAttempt, found := gh.attempts[key]
+	gh.cacheMu.Unlock()
+	if found {
+		return previousAttempt, nil
+	}
+	attemptRun, _, err := gh.actions.GetWorkflowRunAttempt(ctx, user, repo, runID, attempt, nil)
+	if err != nil {
+		return githubops.WorkflowAttempt{}, err
+	}
+	previousAttempt = workflowAttempt(user, repo, attemptRun)
+	gh.cacheMu.Lock()
+	defer gh.cacheMu.Unlock()
+	if gh.attempts == nil {
+		gh.attempts = map[runAttemptKey]githubops.WorkflowAttempt{}
+	}
+	gh.attempts[key] = previousAttempt
+	return previousAttempt, nil
+}
+
+// getCompletedRunArtifacts returns the artifacts of a completed workflow run attempt, fetched once. The artifacts of
+// a run still in progress are not fetched, as they are only reported with its final result.
+func (gh *GitHub) getCompletedRunArtifacts(ctx context.Context, user, repo string,
+	run *github.WorkflowRun) []githubops.WorkflowArtifact {
+	if run.GetStatus() != string(githubops.WorkflowStatusCompleted) {
+		return nil
+	}
+	key := runAttemptKey{runID: run.GetID(), attempt: run.GetRunAttempt()}
+	gh.cacheMu.Lock()
+	artifacts, found := gh.artifacts[key]
+	gh.cacheMu.Unlock()
+	if found {
+		return artifacts
+	}
+	artifacts, err := gh.getWorkflowRunArtifacts(ctx, user, repo, run.GetID())
+	if err != nil {
+		// Failing to fetch them is not considered an error, any artifacts fetched so far are returned.
+		gh.logger.Error("could not fetch workflow artifacts", "run_id", run.GetID(), "error", err.Error())
+		return artifacts
+	}
+	gh.cacheMu.Lock()
+	defer gh.cacheMu.Unlock()
+	if gh.artifacts == nil {
+		gh.artifacts = map[runAttemptKey][]githubops.WorkflowArtifact{}
+	}
+	gh.artifacts[key] = artifacts
+	return artifacts
+}
+
+// getWorkflowRunArtifacts returns the artifacts of a workflow run. On failure, any artifacts fetched so far are
+// returned with the error.
+func (gh *GitHub) getWorkflowRunArtifacts(ctx context.Context, user, repo string,
+	runID int64) ([]githubops.WorkflowArtifact, error) {
+	artifactsListOptions := github.ListOptions{
+		Page:    0,
+		PerPage: 30, //default 30, range [0-100]
+	}
+	var resultArtifacts []githubops.WorkflowArtifact
+	for {
+		artifacts, artifactsResp, err := gh.actions.ListWorkflowRunArtifacts(ctx, user, repo, runID,
+			&artifactsListOptions)
+		if err != nil {
+			return resultArtifacts, err
+		}
+		for _, artifact := range artifacts.Artifacts {
+			resultArtifacts = append(resultArtifacts, githubops.WorkflowArtifact{
+				Id:   strconv.FormatInt(artifact.GetID(), 10),
+				Name: artifact.GetName(),
+				Url: fmt.Sprintf("https://github.com/%s/%s/actions/runs/%d/artifacts/%d",
+					user, repo, runID, artifact.GetID()),
+				ApiUrl: artifact.GetURL(),
+			})
+		}
+		if artifactsResp.NextPage == 0 {
+			break
+		}
+		artifactsListOptions.Page = artifactsResp.NextPage
+	}
+	return resultArtifacts, nil
+}
+
+// getWorkflowRunBillableMS returns the billable time of a completed workflow run over all runner environments, when
+// billableTime is enabled. It is fetched once per run attempt, even on failure. GitHub does not report it for every
+// repo, e.g. public ones, in which case or on failure it is 0.
+func (gh *GitHub) getWorkflowRunBillableMS(ctx context.Context, user, repo string, run *github.WorkflowRun) int64 {
+	if !gh.billableTime || run.GetStatus() != string(githubops.WorkflowStatusCompleted) {
+		return 0
+	}
+	key := runAttemptKey{runID: run.GetID(), attempt: run.GetRunAttempt()}
+	gh.cacheMu.Lock()
+	billableMS, found := gh.billableMS[key]
+	gh.cacheMu.Unlock()
+	if found {
+		return billableMS
+	}
+	usage, _, err := gh.actions.GetWorkflowRunUsageByID(ctx, user, repo, run.GetID())
+	if err != nil {
+		gh.logger.Debug("could not fetch workflow run usage", "run_id", run.GetID(), "error", err.Error())
+	} else if usage.Billable != nil {
+		for _, bill := range *usage.Billable {
+			billableMS += bill.GetTotalMS()
+		}
+	}
+	gh.cacheMu.Lock()
+	if gh.billableMS == nil {
+		gh.billableMS = map[runAttemptKey]int64{}
+	}
+	gh.billableMS[key] = billableMS
+	gh.cacheMu.Unlock()
+	return billableMS
+}
+
+// groupWorkflowRuns groups runs by their workflow, keeping the order in which each workflow first appears.
+// Within a group the latest run comes first.
+func groupWorkflowRuns(runs []*github.WorkflowRun) [][]*github.WorkflowRun {
+	var groups [][]*github.WorkflowRun
+	groupIndex := map[int64]int{}
+	for _, run := range runs {
+		index, ok := groupIndex[run.GetWorkflowID()]
+		if !ok {
+			groupIndex[run.GetWorkflowID()] = len(groups)
+			groups = append(groups, []*github.WorkflowRun{run})
+			continue
+		}
+		groups[index] = append(groups[index], run)
+	}
+	for _, group := range groups {
+		sort.SliceStable(group, func(i, j int) bool {
+			if !group[i].GetCreatedAt().Equal(group[j].GetCreatedAt()) {
+				return group[i].GetCreatedAt().After(group[j].GetCreatedAt().Time)
+			}
+			return group[i].GetID() > group[j].GetID()
+		})
+	}
+	return groups
+}
+
+// workflowAttempt converts a superseded workflow run or attempt to a githubops.WorkflowAttempt.
+func workflowAttempt(user, repo string, run *github.WorkflowRun) githubops.WorkflowAttempt {
+	attempt := githubops.WorkflowAttempt{
+		RunID:      strconv.FormatInt(run.GetID(), 10),
+		RunAttempt: run.GetRunAttempt(),
+		Status:     githubops.WorkflowStatus(run.GetStatus()),
+		Result:     githubops.WorkflowConclusion(run.GetConclusion()),
+		Url:        fmt.Sprintf("https://github.com/%s/%s/actions/runs/%d", user, repo, run.GetID()),
+	}
+	if attempt.RunAttempt > 0 {
+		attempt.Url += fmt.Sprintf("/attempts/%d", attempt.RunAttempt)
+	}
+	return attempt
+}
+
+// metricsTransport records the API calls, by endpoint and status code, and the remaining rate limit of the responses.
+type metricsTransport struct {
+	metrics metrics.Metrics
+	next    http.RoundTripper
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		t.metrics.ObserveGitHubAPICall(apiEndpoint(req.URL.Path), 0)
+		return resp, err
+	}
+	t.metrics.ObserveGitHubAPICall(apiEndpoint(req.URL.Path), resp.StatusCode)
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err == nil {
+		t.metrics.SetGitHubRateLimitRemaining(remaining)
+	}
+	return resp, nil
+}
+
+// apiEndpoint returns the API path with its owner, repo, commit and IDs replaced by placeholders, e.g.
+// /repos/:owner/:repo/actions/runs/:id/artifacts, so that the calls are recorded by endpoint.
+func apiEndpoint(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		switch {
+		case i == 2 && segments[1] == "repos":
+			segments[i] = ":owner"
+		case i == 3 && segments[1] == "repos":
+			segments[i] = ":repo"
+		case i > 0 && segments[i-1] == "commits":
+			segments[i] = ":ref"
+		case len(segment) > 0 && strings.Trim(segment, "0123456789") == "":
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
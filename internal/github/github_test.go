@@ -1,25 +1,40 @@
 package github
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"github.com/google/go-github/v57/github"
+	"io"
 	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
 	"radicle-github-actions-adapter/app/githubops"
+	"radicle-github-actions-adapter/internal/coverage"
+	"radicle-github-actions-adapter/internal/junit"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 type MockGitHub struct {
 	repos   Repos
 	actions Actions
+	checks  Checks
 }
 
 type Repos struct{}
-type Actions struct{}
+type Actions struct {
+	attemptCalls   int
+	artifactsCalls int
+	usageCalls     int
+}
+type Checks struct{}
 
 func (r *Repos) GetCommit(ctx context.Context, owner, repo, sha string,
 	opts *github.ListOptions) (*github.RepositoryCommit,
@@ -32,6 +47,15 @@ func (r *Repos) GetCommit(ctx context.Context, owner, repo, sha string,
 
 func (a *Actions) ListRepositoryWorkflowRuns(ctx context.Context, owner, repo string,
 	opts *github.ListWorkflowRunsOptions) (*github.WorkflowRuns, *github.Response, error) {
+	if owner == "rerun_owner" {
+		return &github.WorkflowRuns{
+			WorkflowRuns: []*github.WorkflowRun{
+				mockWorkflowRun(30, 2, "build", "push", 2, githubops.WorkflowResultSuccess, 2),
+				mockWorkflowRun(20, 1, "test", "push", 1, githubops.WorkflowResultSuccess, 1),
+				mockWorkflowRun(10, 1, "test", "pull_request", 1, githubops.WorkflowResultFailure, 0),
+			},
+		}, &github.Response{}, nil
+	}
 	// in order to mock this function we will use the repo to return the appropriate amount of workflows
 	if owner == "repo_owner" {
 		totalWorkflows, err := strconv.Atoi(repo)
@@ -50,13 +74,14 @@ func (a *Actions) ListRepositoryWorkflowRuns(ctx context.Context, owner, repo st
 		for i := 0; i < totalWorkflows; i++ {
 			workId := int64(i)
 			workName := "work " + strconv.Itoa(i)
-			statusCompleted := githubops.WorkflowStatusCompleted
-			result := githubops.WorkflowResultSuccess
+			statusCompleted := string(githubops.WorkflowStatusCompleted)
+			result := string(githubops.WorkflowResultSuccess)
 			if i%2 == 0 {
-				result = githubops.WorkflowResultFailure
+				result = string(githubops.WorkflowResultFailure)
 			}
 			runs.WorkflowRuns = append(runs.WorkflowRuns, &github.WorkflowRun{
 				ID:         &workId,
+				WorkflowID: &workId,
 				Name:       &workName,
 				Status:     &statusCompleted,
 				Conclusion: &result,
@@ -69,6 +94,10 @@ func (a *Actions) ListRepositoryWorkflowRuns(ctx context.Context, owner, repo st
 
 func (a *Actions) ListWorkflowRunArtifacts(ctx context.Context, owner, repo string, runID int64,
 	opts *github.ListOptions) (*github.ArtifactList, *github.Response, error) {
+	a.artifactsCalls++
+	if owner == "rerun_owner" {
+		return &github.ArtifactList{}, &github.Response{}, nil
+	}
 	// in order to mock this function we will use the repo to return the appropriate amount of workflows
 	if owner == "repo_owner" {
 		totalArtifacts, err := strconv.Atoi(repo)
@@ -99,6 +128,122 @@ func (a *Actions) ListWorkflowRunArtifacts(ctx context.Context, owner, repo stri
 	return nil, nil, errors.New("an error occurred")
 }
 
+func (a *Actions) GetWorkflowRunAttempt(ctx context.Context, owner, repo string, runID int64, attemptNumber int,
+	opts *github.WorkflowRunAttemptOptions) (*github.WorkflowRun, *github.Response, error) {
+	a.attemptCalls++
+	if owner == "rerun_owner" && runID == 30 && attemptNumber == 1 {
+		return mockWorkflowRun(30, 2, "build", "push", 1, githubops.WorkflowResultFailure, 1),
+			&github.Response{}, nil
+	}
+	return nil, nil, errors.New("an error occurred")
+}
+
+func (a *Actions) GetWorkflowRunUsageByID(ctx context.Context, owner, repo string, runID int64) (
+	*github.WorkflowRunUsage, *github.Response, error) {
+	a.usageCalls++
+	if owner == "rerun_owner" && runID == 30 {
+		return &github.WorkflowRunUsage{
+			Billable: &github.WorkflowRunBillMap{
+				"UBUNTU":  &github.WorkflowRunBill{TotalMS: github.Int64(60000)},
+				"WINDOWS": &github.WorkflowRunBill{TotalMS: github.Int64(30000)},
+			},
+		}, &github.Response{}, nil
+	}
+	return nil, nil, errors.New("an error occurred")
+}
+
+func (a *Actions) ListWorkflowJobs(ctx context.Context, owner, repo string, runID int64,
+	opts *github.ListWorkflowJobsOptions) (*github.Jobs, *github.Response, error) {
+	if owner == "rerun_owner" && runID == 10 {
+		if opts.Page == 0 {
+			return &github.Jobs{Jobs: []*github.WorkflowJob{{ID: github.Int64(11)}}}, &github.Response{NextPage: 2},
+				nil
+		}
+		return &github.Jobs{Jobs: []*github.WorkflowJob{{ID: github.Int64(12)}, {ID: github.Int64(13)}}},
+			&github.Response{}, nil
+	}
+	return nil, nil, errors.New("an error occurred")
+}
+
+func (c *Checks) ListCheckRunAnnotations(ctx context.Context, owner, repo string, checkRunID int64,
+	opts *github.ListOptions) ([]*github.CheckRunAnnotation, *github.Response, error) {
+	switch checkRunID {
+	case 11:
+		return []*github.CheckRunAnnotation{
+			{Path: github.String("main.go"), StartLine: github.Int(3), EndLine: github.Int(3),
+				AnnotationLevel: github.String("warning"), Title: github.String("lint"),
+				Message: github.String("unused variable")},
+		}, &github.Response{}, nil
+	case 12:
+		return nil, &github.Response{}, nil
+	case 13:
+		return []*github.CheckRunAnnotation{
+			{Path: github.String(".github"), AnnotationLevel: github.String("failure"),
+				Message: github.String("Process completed with exit code 1.")},
+		}, &github.Response{}, nil
+	}
+	return nil, nil, errors.New("an error occurred")
+}
+
+func (a *Actions) DownloadArtifact(ctx context.Context, owner, repo string, artifactID int64,
+	maxRedirects int) (*url.URL, *github.Response, error) {
+	if owner == "rerun_owner" {
+		return &url.URL{Scheme: "https", Host: "artifacts.example", Path: fmt.Sprintf("/%d.zip", artifactID)},
+			&github.Response{}, nil
+	}
+	return nil, nil, errors.New("an error occurred")
+}
+
+// ArtifactsClient serves zip files with the given files at /<artifact ID>.zip.
+type ArtifactsClient struct {
+	artifacts map[string]map[string]string
+}
+
+func (c *ArtifactsClient) Do(req *http.Request) (*http.Response, error) {
+	files, found := c.artifacts[req.URL.Path]
+	if !found {
+		return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found",
+			Body: io.NopCloser(strings.NewReader(""))}, nil
+	}
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	for name, content := range files {
+		file, err := writer.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		_, err = file.Write([]byte(content))
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := writer.Close()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(&archive)}, nil
+}
+
+func mockWorkflowRun(id, workflowID int64, name, event string, attempt int,
+	conclusion githubops.WorkflowConclusion, createdAtMinutes int) *github.WorkflowRun {
+	return &github.WorkflowRun{
+		ID:         github.Int64(id),
+		WorkflowID: github.Int64(workflowID),
+		Name:       github.String(name),
+		Event:      github.String(event),
+		HeadBranch: github.String("main"),
+		RunNumber:  github.Int(int(id)),
+		HTMLURL:    github.String(fmt.Sprintf("https://github.com/rerun_owner/2/actions/runs/%d", id)),
+		RunAttempt: github.Int(attempt),
+		Status:     github.String(string(githubops.WorkflowStatusCompleted)),
+		Conclusion: github.String(string(conclusion)),
+		CreatedAt: &github.Timestamp{Time: time.Date(2024, 1, 1, 0, createdAtMinutes, 0, 0,
+			time.UTC)},
+		RunStartedAt: &github.Timestamp{Time: time.Date(2024, 1, 1, 0, createdAtMinutes, 30, 0,
+			time.UTC)},
+	}
+}
+
 func TestGitHub_CheckRepoCommit(t *testing.T) {
 	mGH := MockGitHub{}
 	type fields struct {
@@ -180,6 +325,7 @@ func TestGitHub_GetRepoCommitWorkflows(t *testing.T) {
 		user   string
 		repo   string
 		commit string
+		filter githubops.WorkflowRunsFilter
 	}
 	tests := []struct {
 		name    string
@@ -254,6 +400,124 @@ func TestGitHub_GetRepoCommitWorkflows(t *testing.T) {
 			want:    nil,
 			wantErr: false,
 		},
+		{
+			name: "GetRepoCommitWorkflows returns the latest run of each workflow with previous attempts",
+			fields: fields{
+				logger:  slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+				pat:     "github_pat",
+				repos:   &mGH.repos,
+				actions: &mGH.actions,
+			},
+			args: args{
+				ctx:    context.Background(),
+				user:   "rerun_owner",
+				repo:   "2",
+				commit: "commit_hash",
+			},
+			want: []githubops.WorkflowResult{
+				{
+					WorkflowID:   "30",
+					WorkflowName: "build",
+					Status:       githubops.WorkflowStatusCompleted,
+					Result:       githubops.WorkflowResultSuccess,
+					Event:        "push",
+					HeadBranch:   "main",
+					RunNumber:    30,
+					RunAttempt:   2,
+					CreatedAt:    time.Date(2024, 1, 1, 0, 2, 0, 0, time.UTC),
+					StartedAt:    time.Date(2024, 1, 1, 0, 2, 30, 0, time.UTC),
+					HTMLURL:      "https://github.com/rerun_owner/2/actions/runs/30",
+					BillableMS:   90000,
+					PreviousAttempts: []githubops.WorkflowAttempt{
+						{
+							RunID:      "30",
+							RunAttempt: 1,
+							Status:     githubops.WorkflowStatusCompleted,
+							Result:     githubops.WorkflowResultFailure,
+							Url:        "https://github.com/rerun_owner/2/actions/runs/30/attempts/1",
+						},
+					},
+				},
+				{
+					WorkflowID:   "20",
+					WorkflowName: "test",
+					Status:       githubops.WorkflowStatusCompleted,
+					Result:       githubops.WorkflowResultSuccess,
+					Event:        "push",
+					HeadBranch:   "main",
+					RunNumber:    20,
+					RunAttempt:   1,
+					CreatedAt:    time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC),
+					StartedAt:    time.Date(2024, 1, 1, 0, 1, 30, 0, time.UTC),
+					HTMLURL:      "https://github.com/rerun_owner/2/actions/runs/20",
+					PreviousAttempts: []githubops.WorkflowAttempt{
+						{
+							RunID:      "10",
+							RunAttempt: 1,
+							Status:     githubops.WorkflowStatusCompleted,
+							Result:     githubops.WorkflowResultFailure,
+							Url:        "https://github.com/rerun_owner/2/actions/runs/10/attempts/1",
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "GetRepoCommitWorkflows skips the runs of other events",
+			fields: fields{
+				logger:  slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+				pat:     "github_pat",
+				repos:   &mGH.repos,
+				actions: &mGH.actions,
+			},
+			args: args{
+				ctx:    context.Background(),
+				user:   "rerun_owner",
+				repo:   "2",
+				commit: "commit_hash",
+				filter: githubops.WorkflowRunsFilter{Events: []string{"push"}, HeadBranch: "main"},
+			},
+			want: []githubops.WorkflowResult{
+				{
+					WorkflowID:   "30",
+					WorkflowName: "build",
+					Status:       githubops.WorkflowStatusCompleted,
+					Result:       githubops.WorkflowResultSuccess,
+					Event:        "push",
+					HeadBranch:   "main",
+					RunNumber:    30,
+					RunAttempt:   2,
+					CreatedAt:    time.Date(2024, 1, 1, 0, 2, 0, 0, time.UTC),
+					StartedAt:    time.Date(2024, 1, 1, 0, 2, 30, 0, time.UTC),
+					HTMLURL:      "https://github.com/rerun_owner/2/actions/runs/30",
+					BillableMS:   90000,
+					PreviousAttempts: []githubops.WorkflowAttempt{
+						{
+							RunID:      "30",
+							RunAttempt: 1,
+							Status:     githubops.WorkflowStatusCompleted,
+							Result:     githubops.WorkflowResultFailure,
+							Url:        "https://github.com/rerun_owner/2/actions/runs/30/attempts/1",
+						},
+					},
+				},
+				{
+					WorkflowID:   "20",
+					WorkflowName: "test",
+					Status:       githubops.WorkflowStatusCompleted,
+					Result:       githubops.WorkflowResultSuccess,
+					Event:        "push",
+					HeadBranch:   "main",
+					RunNumber:    20,
+					RunAttempt:   1,
+					CreatedAt:    time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC),
+					StartedAt:    time.Date(2024, 1, 1, 0, 1, 30, 0, time.UTC),
+					HTMLURL:      "https://github.com/rerun_owner/2/actions/runs/20",
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "GetRepoCommitWorkflows fails with invalid user",
 			fields: fields{
@@ -275,12 +539,14 @@ func TestGitHub_GetRepoCommitWorkflows(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			gh := &GitHub{
-				logger:  tt.fields.logger,
-				pat:     tt.fields.pat,
-				repos:   tt.fields.repos,
-				actions: tt.fields.actions,
+				logger:       tt.fields.logger,
+				pat:          tt.fields.pat,
+				repos:        tt.fields.repos,
+				actions:      tt.fields.actions,
+				billableTime: true,
 			}
-			got, err := gh.GetRepoCommitWorkflows(tt.args.ctx, tt.args.user, tt.args.repo, tt.args.commit)
+			got, err := gh.GetRepoCommitWorkflows(tt.args.ctx, tt.args.user, tt.args.repo, tt.args.commit,
+				tt.args.filter)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetRepoCommitWorkflows() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -296,3 +562,414 @@ func TestGitHub_GetRepoCommitWorkflows(t *testing.T) {
 		})
 	}
 }
+
+func TestGitHub_GetRepoCommitWorkflowsFetchesCompletedAttemptsOnce(t *testing.T) {
+	mGH := MockGitHub{}
+	gh := &GitHub{
+		logger:       slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+		actions:      &mGH.actions,
+		billableTime: true,
+	}
+	var results [][]githubops.WorkflowResult
+	for i := 0; i < 3; i++ {
+		result, err := gh.GetRepoCommitWorkflows(context.Background(), "rerun_owner", "2", "commit_hash",
+			githubops.WorkflowRunsFilter{})
+		if err != nil {
+			t.Fatalf("GetRepoCommitWorkflows() error = %v", err)
+		}
+		results = append(results, result)
+	}
+	if !reflect.DeepEqual(results[0], results[2]) {
+		t.Errorf("GetRepoCommitWorkflows() got = %+v, then %+v, want the same results", results[0], results[2])
+	}
+	if mGH.actions.attemptCalls != 1 || mGH.actions.artifactsCalls != 2 || mGH.actions.usageCalls != 2 {
+		t.Errorf("GetRepoCommitWorkflows() fetched %d attempts, %d artifact lists and %d usages, want 1, 2 and 2",
+			mGH.actions.attemptCalls, mGH.actions.artifactsCalls, mGH.actions.usageCalls)
+	}
+}
+
+func TestGitHub_GetRepoCommitWorkflowsWithoutBillableTime(t *testing.T) {
+	mGH := MockGitHub{}
+	gh := &GitHub{
+		logger:  slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+		actions: &mGH.actions,
+	}
+	results, err := gh.GetRepoCommitWorkflows(context.Background(), "rerun_owner", "2", "commit_hash",
+		githubops.WorkflowRunsFilter{})
+	if err != nil {
+		t.Fatalf("GetRepoCommitWorkflows() error = %v", err)
+	}
+	for _, result := range results {
+		if result.BillableMS != 0 {
+			t.Errorf("GetRepoCommitWorkflows() got billable time %d of run %s, want none", result.BillableMS,
+				result.WorkflowID)
+		}
+	}
+	if mGH.actions.usageCalls != 0 {
+		t.Errorf("GetRepoCommitWorkflows() fetched %d usages, want none", mGH.actions.usageCalls)
+	}
+}
+
+func TestGitHub_GetWorkflowRunAnnotations(t *testing.T) {
+	mGH := MockGitHub{}
+	gh := &GitHub{
+		logger:  slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+		actions: &mGH.actions,
+		checks:  &mGH.checks,
+	}
+	tests := []struct {
+		name    string
+		user    string
+		runID   string
+		want    []githubops.WorkflowAnnotation
+		wantErr bool
+	}{
+		{
+			name:  "GetWorkflowRunAnnotations returns the annotations of all jobs",
+			user:  "rerun_owner",
+			runID: "10",
+			want: []githubops.WorkflowAnnotation{
+				{Path: "main.go", StartLine: 3, EndLine: 3, Level: "warning", Title: "lint",
+					Message: "unused variable"},
+				{Path: ".github", Level: "failure", Message: "Process completed with exit code 1."},
+			},
+		},
+		{
+			name:    "GetWorkflowRunAnnotations fails when the jobs cannot be fetched",
+			user:    "invalid_owner",
+			runID:   "10",
+			wantErr: true,
+		},
+		{
+			name:    "GetWorkflowRunAnnotations fails with an invalid run ID",
+			user:    "rerun_owner",
+			runID:   "run",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gh.GetWorkflowRunAnnotations(context.Background(), tt.user, "2", tt.runID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetWorkflowRunAnnotations() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetWorkflowRunAnnotations() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitHub_GetArtifactTestSummary(t *testing.T) {
+	mGH := MockGitHub{}
+	gh := &GitHub{
+		logger:  slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+		actions: &mGH.actions,
+		client: &ArtifactsClient{artifacts: map[string]map[string]string{
+			"/1.zip": {
+				"unit/report.xml": `<testsuite><testcase name="TestPass"/>` +
+					`<testcase name="TestFail"><failure message="boom"/></testcase></testsuite>`,
+				"e2e/REPORT.XML": `<testsuites><testsuite><testcase name="TestSkip"><skipped/></testcase>` +
+					`</testsuite></testsuites>`,
+				"coverage.xml": `<coverage line-rate="0.9">`,
+				"summary.txt":  "2 passed",
+			},
+		}},
+	}
+	tests := []struct {
+		name       string
+		user       string
+		artifactID string
+		want       *githubops.TestSummary
+		wantErr    bool
+	}{
+		{
+			name:       "GetArtifactTestSummary summarizes the JUnit reports of the artifact",
+			user:       "rerun_owner",
+			artifactID: "1",
+			want: &githubops.TestSummary{Total: 3, Passed: 1, Failed: 1, Skipped: 1,
+				FailedTests: []githubops.FailedTest{{Name: "TestFail", Message: "boom"}}},
+		},
+		{
+			name:       "GetArtifactTestSummary fails when the artifact cannot be downloaded",
+			user:       "rerun_owner",
+			artifactID: "2",
+			wantErr:    true,
+		},
+		{
+			name:       "GetArtifactTestSummary fails without a download URL",
+			user:       "invalid_owner",
+			artifactID: "1",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gh.GetArtifactTestSummary(context.Background(), tt.user, "2", tt.artifactID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetArtifactTestSummary() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetArtifactTestSummary() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitHub_GetArtifactCoverage(t *testing.T) {
+	mGH := MockGitHub{}
+	gh := &GitHub{
+		logger:  slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+		actions: &mGH.actions,
+		client: &ArtifactsClient{artifacts: map[string]map[string]string{
+			"/1.zip": {
+				"coverage.out":  "mode: set\na.go:3.10,5.2 2 1\na.go:7.10,9.2 2 0\n",
+				"web/lcov.info": "SF:a.js\nDA:1,1\nLF:4\nLH:3\nend_of_record\n",
+				"report.xml":    `<testsuite><testcase name="TestPass"/></testsuite>`,
+			},
+			"/2.zip": {
+				"summary.txt": "2 passed",
+			},
+		}},
+	}
+	tests := []struct {
+		name       string
+		user       string
+		artifactID string
+		want       *githubops.Coverage
+		wantErr    bool
+	}{
+		{
+			name:       "GetArtifactCoverage adds up the coverage reports of the artifact",
+			user:       "rerun_owner",
+			artifactID: "1",
+			want:       &githubops.Coverage{Covered: 5, Total: 8},
+		},
+		{
+			name:       "GetArtifactCoverage fails without coverage reports in the artifact",
+			user:       "rerun_owner",
+			artifactID: "2",
+			wantErr:    true,
+		},
+		{
+			name:       "GetArtifactCoverage fails when the artifact cannot be downloaded",
+			user:       "rerun_owner",
+			artifactID: "3",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gh.GetArtifactCoverage(context.Background(), tt.user, "2", tt.artifactID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetArtifactCoverage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetArtifactCoverage() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCoverageReport(t *testing.T) {
+	profile := "mode: set\na.go:3.10,5.2 2 1\na.go:7.10,9.2 2 0\n"
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	file, err := writer.Create("coverage.out")
+	if err == nil {
+		_, err = file.Write([]byte(profile))
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		maxSize  int64
+		wantSize int64
+		want     coverage.Report
+		wantErr  bool
+	}{
+		{
+			name:     "parseCoverageReport adds a file within the size limit",
+			maxSize:  int64(len(profile)),
+			wantSize: int64(len(profile)),
+			want:     coverage.Report{Covered: 2, Total: 4},
+		},
+		{
+			name:    "parseCoverageReport skips a file larger than the size limit",
+			maxSize: int64(len(profile)) - 1,
+			wantErr: true,
+		},
+		{
+			name:    "parseCoverageReport skips any file once the budget is exhausted",
+			maxSize: -1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var report coverage.Report
+			size, err := parseCoverageReport(reader.File[0], &report, tt.maxSize)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCoverageReport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if size != tt.wantSize || !reflect.DeepEqual(report, tt.want) {
+				t.Errorf("parseCoverageReport() got = %d, %+v, want %d, %+v", size, report, tt.wantSize, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTestReport(t *testing.T) {
+	xmlReport := `<testsuite><testcase name="TestPass"/><testcase name="TestFail"><failure message="boom"/>` +
+		`</testcase></testsuite>`
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	file, err := writer.Create("report.xml")
+	if err == nil {
+		_, err = file.Write([]byte(xmlReport))
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		maxSize  int64
+		wantSize int64
+		want     junit.Report
+		wantErr  bool
+	}{
+		{
+			name:     "parseTestReport adds a file within the size limit",
+			maxSize:  int64(len(xmlReport)),
+			wantSize: int64(len(xmlReport)),
+			want: junit.Report{Total: 2, Passed: 1, Failed: 1,
+				Failures: []junit.Failure{{Name: "TestFail", Message: "boom"}}},
+		},
+		{
+			name:    "parseTestReport skips a file larger than the size limit",
+			maxSize: int64(len(xmlReport)) - 1,
+			wantErr: true,
+		},
+		{
+			name:    "parseTestReport skips any file once the budget is exhausted",
+			maxSize: -1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var report junit.Report
+			size, err := parseTestReport(reader.File[0], &report, tt.maxSize)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTestReport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if size != tt.wantSize || !reflect.DeepEqual(report, tt.want) {
+				t.Errorf("parseTestReport() got = %d, %+v, want %d, %+v", size, report, tt.wantSize, tt.want)
+			}
+		})
+	}
+}
+
+type MockMetrics struct {
+	apiCalls           map[string]int
+	rateLimitRemaining *int
+}
+
+func (m *MockMetrics) ObserveJob(event, result string, duration time.Duration) {}
+
+func (m *MockMetrics) ObserveTimeToFirstRun(duration time.Duration) {}
+
+func (m *MockMetrics) ObserveGitHubAPICall(endpoint string, statusCode int) {
+	m.apiCalls[endpoint+" "+strconv.Itoa(statusCode)]++
+}
+
+func (m *MockMetrics) SetGitHubRateLimitRemaining(remaining int) {
+	m.rateLimitRemaining = &remaining
+}
+
+func (m *MockMetrics) ObserveRadicleAction(action string, err error) {}
+
+func (m *MockMetrics) ObserveClone(duration time.Duration) {}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestMetricsTransport_RoundTrip(t *testing.T) {
+	mockMetrics := &MockMetrics{apiCalls: map[string]int{}}
+	transport := &metricsTransport{metrics: mockMetrics, next: roundTripperFunc(
+		func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path == "/rate_limit" {
+				return nil, errors.New("connection refused")
+			}
+			header := http.Header{}
+			header.Set("X-RateLimit-Remaining", "4321")
+			return &http.Response{StatusCode: http.StatusNotFound, Header: header, Body: http.NoBody}, nil
+		})}
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get("https://api.github.com/repos/owner/repo/actions/runs/123/artifacts")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	_, err = client.Get("https://api.github.com/rate_limit")
+	if err == nil {
+		t.Fatalf("Get() expected error")
+	}
+
+	wantAPICalls := map[string]int{
+		"/repos/:owner/:repo/actions/runs/:id/artifacts 404": 1,
+		"/rate_limit 0": 1,
+	}
+	if !reflect.DeepEqual(mockMetrics.apiCalls, wantAPICalls) {
+		t.Errorf("RoundTrip() recorded API calls %v, want %v", mockMetrics.apiCalls, wantAPICalls)
+	}
+	if mockMetrics.rateLimitRemaining == nil || *mockMetrics.rateLimitRemaining != 4321 {
+		t.Errorf("RoundTrip() recorded rate limit remaining %v, want 4321", mockMetrics.rateLimitRemaining)
+	}
+}
+
+func TestApiEndpoint(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/repos/owner/repo/actions/runs", want: "/repos/:owner/:repo/actions/runs"},
+		{path: "/repos/owner/repo/commits/0123abc", want: "/repos/:owner/:repo/commits/:ref"},
+		{path: "/repos/owner/repo/commits/0123", want: "/repos/:owner/:repo/commits/:ref"},
+		{
+			path: "/repos/owner/repo/actions/runs/12/attempts/1",
+			want: "/repos/:owner/:repo/actions/runs/:id/attempts/:id",
+		},
+		{path: "/repos/owner/repo/check-runs/34/annotations", want: "/repos/:owner/:repo/check-runs/:id/annotations"},
+		{path: "/rate_limit", want: "/rate_limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := apiEndpoint(tt.path); got != tt.want {
+				t.Errorf("apiEndpoint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
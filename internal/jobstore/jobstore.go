@@ -0,0 +1,125 @@
+package jobstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"radicle-github-actions-adapter/app/jobs"
+	"sort"
+	"strings"
+	"time"
+)
+
+const jobFileSuffix string = ".json"
+
+type JobStore struct {
+	directory string
+	logger    *slog.Logger
+}
+
+// NewJobStore returns a JobStore which persists every job as a JSON file under directory.
+func NewJobStore(directory string, logger *slog.Logger) *JobStore {
+	return &JobStore{
+		directory: directory,
+		logger:    logger,
+	}
+}
+
+// Save creates or replaces the stored job. UpdatedAt is always refreshed.
+func (js *JobStore) Save(ctx context.Context, job jobs.Job) error {
+	if len(job.ID) == 0 {
+		return errors.New("job has no ID")
+	}
+	now := time.Now().UTC()
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = now
+	}
+	job.UpdatedAt = now
+	err := os.MkdirAll(js.directory, 0o700)
+	if err != nil {
+		js.logger.Error("could not create jobs directory", "directory", js.directory, "error", err.Error())
+		return err
+	}
+	content, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		js.logger.Error("could not encode job", "id", job.ID, "error", err.Error())
+		return err
+	}
+	// Write to a temporary file first so that a crash never leaves a half written job behind.
+	tmpFile := js.jobPath(job.ID) + ".tmp"
+	err = os.WriteFile(tmpFile, content, 0o600)
+	if err != nil {
+		js.logger.Error("could not write job", "id", job.ID, "error", err.Error())
+		return err
+	}
+	err = os.Rename(tmpFile, js.jobPath(job.ID))
+	if err != nil {
+		js.logger.Error("could not replace job", "id", job.ID, "error", err.Error())
+		return err
+	}
+	return nil
+}
+
+// Get returns the job with the given id or jobs.ErrJobNotFound.
+func (js *JobStore) Get(ctx context.Context, id string) (*jobs.Job, error) {
+	content, err := os.ReadFile(js.jobPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, jobs.ErrJobNotFound
+	}
+	if err != nil {
+		js.logger.Error("could not read job", "id", id, "error", err.Error())
+		return nil, err
+	}
+	job := jobs.Job{}
+	err = json.Unmarshal(content, &job)
+	if err != nil {
+		js.logger.Error("could not decode job", "id", id, "error", err.Error())
+		return nil, err
+	}
+	return &job, nil
+}
+
+// List returns all stored jobs ordered by creation time. Unreadable job files are skipped.
+func (js *JobStore) List(ctx context.Context) ([]jobs.Job, error) {
+	entries, err := os.ReadDir(js.directory)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		js.logger.Error("could not list jobs directory", "directory", js.directory, "error", err.Error())
+		return nil, err
+	}
+	var result []jobs.Job
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), jobFileSuffix) {
+			continue
+		}
+		job, err := js.Get(ctx, strings.TrimSuffix(entry.Name(), jobFileSuffix))
+		if err != nil {
+			js.logger.Warn("skipping invalid job file", "file", entry.Name(), "error", err.Error())
+			continue
+		}
+		result = append(result, *job)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// Delete removes the stored job with the given id, if any.
+func (js *JobStore) Delete(ctx context.Context, id string) error {
+	err := os.Remove(js.jobPath(id))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		js.logger.Error("could not delete job", "id", id, "error", err.Error())
+		return err
+	}
+	return nil
+}
+
+func (js *JobStore) jobPath(id string) string {
+	return filepath.Join(js.directory, filepath.Base(id)+jobFileSuffix)
+}
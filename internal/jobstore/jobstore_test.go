@@ -0,0 +1,83 @@
+package jobstore
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"radicle-github-actions-adapter/app/jobs"
+	"testing"
+	"time"
+)
+
+func TestJobStore_SaveGetList(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{}))
+	directory := filepath.Join(t.TempDir(), "jobs")
+	js := NewJobStore(directory, logger)
+	ctx := context.Background()
+
+	jobsList, err := js.List(ctx)
+	if err != nil || len(jobsList) != 0 {
+		t.Fatalf("List() on missing directory got = %v, %v, want no jobs and no error", jobsList, err)
+	}
+	_, err = js.Get(ctx, "unknown")
+	if !errors.Is(err, jobs.ErrJobNotFound) {
+		t.Fatalf("Get() unknown job error = %v, want %v", err, jobs.ErrJobNotFound)
+	}
+	if err := js.Save(ctx, jobs.Job{}); err == nil {
+		t.Fatalf("Save() job without ID expected error")
+	}
+
+	first := jobs.Job{ID: "first", Repo: "rad:z123", Commit: "abc", Phase: jobs.JobPhaseTriggered,
+		CreatedAt: time.Now().Add(-time.Minute)}
+	second := jobs.Job{ID: "second", Repo: "rad:z123", Commit: "def", Phase: jobs.JobPhaseWaiting}
+	for _, job := range []jobs.Job{second, first} {
+		if err := js.Save(ctx, job); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	first.Phase = jobs.JobPhaseFinished
+	first.CommentID = "comment_id"
+	if err := js.Save(ctx, first); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got, err := js.Get(ctx, "first")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Phase != jobs.JobPhaseFinished || got.CommentID != "comment_id" || got.UpdatedAt.IsZero() {
+		t.Errorf("Get() got = %+v, want updated job", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(directory, "broken.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	jobsList, err = js.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(jobsList) != 2 || jobsList[0].ID != "first" || jobsList[1].ID != "second" {
+		t.Errorf("List() got = %+v, want jobs first and second ordered by creation", jobsList)
+	}
+}
+
+func TestJobStore_Delete(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{}))
+	js := NewJobStore(filepath.Join(t.TempDir(), "jobs"), logger)
+	ctx := context.Background()
+
+	if err := js.Save(ctx, jobs.Job{ID: "job", Phase: jobs.JobPhaseFinished}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := js.Delete(ctx, "job"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := js.Get(ctx, "job"); !errors.Is(err, jobs.ErrJobNotFound) {
+		t.Errorf("Get() deleted job error = %v, want %v", err, jobs.ErrJobNotFound)
+	}
+	if err := js.Delete(ctx, "job"); err != nil {
+		t.Errorf("Delete() unknown job error = %v, want none", err)
+	}
+}
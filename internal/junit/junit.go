@@ -0,0 +1,126 @@
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxReportSize bounds the size of a report read by Parse.
+const maxReportSize int64 = 16 << 20
+
+// maxMessageLength bounds the length in runes of a failure's message.
+const maxMessageLength = 500
+
+// Report summarizes the test cases of JUnit XML reports.
+type Report struct {
+	Total   int
+	Passed  int
+	Failed  int
+	Skipped int
+	// Flaky tests passed after failing first and are counted as passed too.
+	Flaky    int
+	Failures []Failure
+}
+
+// Failure is a test case that failed, or a flaky one.
+type Failure struct {
+	Name    string
+	Message string
+	Flaky   bool
+}
+
+// testSuite is either the <testsuites> root of a report, a <testsuite> root or a nested <testsuite>.
+type testSuite struct {
+	Suites []testSuite `xml:"testsuite"`
+	Cases  []testCase  `xml:"testcase"`
+}
+
+type testCase struct {
+	Name          string        `xml:"name,attr"`
+	ClassName     string        `xml:"classname,attr"`
+	Failure       *testProblem  `xml:"failure"`
+	Error         *testProblem  `xml:"error"`
+	Skipped       *struct{}     `xml:"skipped"`
+	FlakyFailures []testProblem `xml:"flakyFailure"`
+	FlakyErrors   []testProblem `xml:"flakyError"`
+}
+
+type testProblem struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// Parse reads a JUnit XML report and adds its test cases to the report. Reports larger than maxReportSize are an
+// error and none of their test cases are added.
+func (r *Report) Parse(reader io.Reader) error {
+	limitedReader := &io.LimitedReader{R: reader, N: maxReportSize + 1}
+	var root testSuite
+	err := xml.NewDecoder(limitedReader).Decode(&root)
+	if limitedReader.N <= 0 {
+		return fmt.Errorf("report is larger than %d bytes", maxReportSize)
+	}
+	if err != nil {
+		return err
+	}
+	r.addSuite(root)
+	return nil
+}
+
+func (r *Report) addSuite(suite testSuite) {
+	for _, nested := range suite.Suites {
+		r.addSuite(nested)
+	}
+	for _, tc := range suite.Cases {
+		r.Total++
+		switch {
+		case tc.Failure != nil:
+			r.Failed++
+			r.Failures = append(r.Failures, Failure{Name: tc.fullName(), Message: tc.Failure.message()})
+		case tc.Error != nil:
+			r.Failed++
+			r.Failures = append(r.Failures, Failure{Name: tc.fullName(), Message: tc.Error.message()})
+		case tc.Skipped != nil:
+			r.Skipped++
+		case len(tc.FlakyFailures) > 0 || len(tc.FlakyErrors) > 0:
+			r.Passed++
+			r.Flaky++
+			problems := tc.FlakyErrors
+			if len(tc.FlakyFailures) > 0 {
+				problems = tc.FlakyFailures
+			}
+			r.Failures = append(r.Failures, Failure{Name: tc.fullName(), Message: problems[0].message(), Flaky: true})
+		default:
+			r.Passed++
+		}
+	}
+}
+
+// fullName returns the name of the test case prefixed with its class, if any.
+func (tc testCase) fullName() string {
+	if len(tc.ClassName) == 0 {
+		return tc.Name
+	}
+	return tc.ClassName + "." + tc.Name
+}
+
+// message returns the message of the problem, or the first line of its text when it has none, truncated to
+// maxMessageLength.
+func (p testProblem) message() string {
+	if len(p.Message) > 0 {
+		return truncate(p.Message)
+	}
+	text := strings.TrimSpace(p.Text)
+	firstLine, _, _ := strings.Cut(text, "\n")
+	return truncate(firstLine)
+}
+
+// truncate cuts a message longer than maxMessageLength runes and marks it with an ellipsis.
+func truncate(message string) string {
+	runes := []rune(message)
+	if len(runes) <= maxMessageLength {
+		return message
+	}
+	return string(runes[:maxMessageLength]) + "…"
+}
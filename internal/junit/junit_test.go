@@ -0,0 +1,101 @@
+package junit
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReport_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		reports []string
+		want    Report
+		wantErr bool
+	}{
+		{
+			name: "Parse counts the test cases of a testsuites report",
+			reports: []string{`<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="5" failures="1" errors="1">
+  <testsuite name="pkg">
+    <testcase classname="pkg" name="TestPass"/>
+    <testcase classname="pkg" name="TestFail"><failure message="expected 1, got 2">details</failure></testcase>
+    <testcase classname="pkg" name="TestError"><error>panic: nil map
+goroutine 1</error></testcase>
+    <testcase classname="pkg" name="TestSkip"><skipped/></testcase>
+  </testsuite>
+  <testsuite name="nested">
+    <testsuite name="inner">
+      <testcase name="TestFlaky"><flakyFailure message="timeout"/></testcase>
+    </testsuite>
+  </testsuite>
+</testsuites>`},
+			want: Report{Total: 5, Passed: 2, Failed: 2, Skipped: 1, Flaky: 1, Failures: []Failure{
+				{Name: "pkg.TestFail", Message: "expected 1, got 2"},
+				{Name: "pkg.TestError", Message: "panic: nil map"},
+				{Name: "TestFlaky", Message: "timeout", Flaky: true},
+			}},
+		},
+		{
+			name: "Parse adds the test cases of several testsuite reports",
+			reports: []string{
+				`<testsuite name="a"><testcase name="one"/></testsuite>`,
+				`<testsuite name="b"><testcase name="two"><failure message="boom"/></testcase></testsuite>`,
+			},
+			want: Report{Total: 2, Passed: 1, Failed: 1, Failures: []Failure{{Name: "two", Message: "boom"}}},
+		},
+		{
+			name: "Parse truncates long failure messages",
+			reports: []string{`<testsuite><testcase name="long"><failure>` + strings.Repeat("é", 600) +
+				"\nstack</failure></testcase></testsuite>"},
+			want: Report{Total: 1, Failed: 1, Failures: []Failure{
+				{Name: "long", Message: strings.Repeat("é", maxMessageLength) + "…"},
+			}},
+		},
+		{
+			name:    "Parse fails on a malformed report",
+			reports: []string{`<testsuite><testcase name="one">`},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Report
+			var err error
+			for _, report := range tt.reports {
+				err = got.Parse(strings.NewReader(report))
+				if err != nil {
+					break
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// endlessReader reads the same byte forever.
+type endlessReader byte
+
+func (e endlessReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(e)
+	}
+	return len(p), nil
+}
+
+func TestReport_ParseLargeReport(t *testing.T) {
+	var got Report
+	reader := io.MultiReader(strings.NewReader(`<testsuite><testcase name="large"><failure>`), endlessReader('x'))
+	if err := got.Parse(reader); err == nil {
+		t.Fatalf("Parse() of a report larger than %d bytes expected error", maxReportSize)
+	}
+	if !reflect.DeepEqual(got, Report{}) {
+		t.Errorf("Parse() got = %+v, want no test case", got)
+	}
+}
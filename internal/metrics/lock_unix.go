@@ -0,0 +1,19 @@
+//go:build unix
+
+package metrics
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile waits for an exclusive lock of file and returns the function releasing it.
+func lockFile(file *os.File) (func(), error) {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+	}, nil
+}
@@ -0,0 +1,348 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const namePrefix string = "radicle_github_actions_adapter_"
+
+type metricKind string
+
+const (
+	counterKind   metricKind = "counter"
+	gaugeKind     metricKind = "gauge"
+	histogramKind metricKind = "histogram"
+)
+
+// family is a metric with its series by their rendered labels.
+type family struct {
+	name       string
+	help       string
+	kind       metricKind
+	labelNames []string
+	buckets    []float64
+	series     map[string]*series
+}
+
+type series struct {
+	value float64
+	// set is whether a gauge was set by this process, so that it replaces the value written by earlier processes.
+	set bool
+	// bucketCounts are the cumulative counts of a histogram's observations up to each bucket.
+	bucketCounts []float64
+	sum          float64
+	count        float64
+}
+
+// Registry records the adapter's metrics in memory and renders them in the Prometheus text exposition format.
+// As the adapter serves a single event per process, WriteTextfile adds them up across processes in a node_exporter
+// textfile collector file.
+type Registry struct {
+	mu                       sync.Mutex
+	families                 []*family
+	jobs                     *family
+	jobDuration              *family
+	timeToFirstRun           *family
+	gitHubAPICalls           *family
+	gitHubRateLimitRemaining *family
+	radicleActions           *family
+	radicleActionFailures    *family
+	cloneDuration            *family
+}
+
+// NewRegistry returns a Registry without any recorded metric.
+func NewRegistry() *Registry {
+	r := &Registry{}
+	r.jobs = r.newFamily("jobs_total", "Broker jobs served, by event kind and result.", counterKind, nil,
+		"event", "result")
+	r.jobDuration = r.newFamily("job_duration_seconds", "Duration of the broker jobs, by event kind and result.",
+		histogramKind, []float64{10, 30, 60, 120, 300, 600, 1200, 1800, 3600}, "event", "result")
+	r.timeToFirstRun = r.newFamily("time_to_first_run_seconds",
+		"Time from the trigger of a job until its first workflow run was found.", histogramKind,
+		[]float64{5, 10, 30, 60, 120, 300, 600})
+	r.gitHubAPICalls = r.newFamily("github_api_calls_total", "GitHub API calls, by endpoint and status code.",
+		counterKind, nil, "endpoint", "status_code")
+	r.gitHubRateLimitRemaining = r.newFamily("github_rate_limit_remaining",
+		"GitHub API requests remaining in the current rate limit window.", gaugeKind, nil)
+	r.radicleActions = r.newFamily("radicle_actions_total", "Actions sent to radicle-httpd, by action.",
+		counterKind, nil, "action")
+	r.radicleActionFailures = r.newFamily("radicle_action_failures_total",
+		"Actions sent to radicle-httpd that failed, by action.", counterKind, nil, "action")
+	r.cloneDuration = r.newFamily("clone_duration_seconds", "Duration of the repo clones.", histogramKind,
+		[]float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60})
+	return r
+}
+
+func (r *Registry) newFamily(name, help string, kind metricKind, buckets []float64, labelNames ...string) *family {
+	f := &family{
+		name:       namePrefix + name,
+		help:       help,
+		kind:       kind,
+		labelNames: labelNames,
+		buckets:    buckets,
+		series:     map[string]*series{},
+	}
+	r.families = append(r.families, f)
+	return f
+}
+
+func (r *Registry) ObserveJob(event, result string, duration time.Duration) {
+	r.add(r.jobs, 1, event, result)
+	r.observe(r.jobDuration, duration.Seconds(), event, result)
+}
+
+func (r *Registry) ObserveTimeToFirstRun(duration time.Duration) {
+	r.observe(r.timeToFirstRun, duration.Seconds())
+}
+
+func (r *Registry) ObserveGitHubAPICall(endpoint string, statusCode int) {
+	r.add(r.gitHubAPICalls, 1, endpoint, strconv.Itoa(statusCode))
+}
+
+func (r *Registry) SetGitHubRateLimitRemaining(remaining int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.gitHubRateLimitRemaining.get("")
+	s.value, s.set = float64(remaining), true
+}
+
+func (r *Registry) ObserveRadicleAction(action string, err error) {
+	r.add(r.radicleActions, 1, action)
+	if err != nil {
+		r.add(r.radicleActionFailures, 1, action)
+	}
+}
+
+func (r *Registry) ObserveClone(duration time.Duration) {
+	r.observe(r.cloneDuration, duration.Seconds())
+}
+
+func (r *Registry) add(f *family, value float64, labelValues ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f.get(f.labels(labelValues)).value += value
+}
+
+func (r *Registry) observe(f *family, value float64, labelValues ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := f.get(f.labels(labelValues))
+	for i, bucket := range f.buckets {
+		if value <= bucket {
+			s.bucketCounts[i]++
+		}
+	}
+	s.sum += value
+	s.count++
+}
+
+// get returns the series with the rendered labels, created if needed.
+func (f *family) get(labels string) *series {
+	s, found := f.series[labels]
+	if !found {
+		s = &series{bucketCounts: make([]float64, len(f.buckets))}
+		f.series[labels] = s
+	}
+	return s
+}
+
+// labels renders the label pairs of the family's series with labelValues, e.g. event="push",result="success".
+func (f *family) labels(labelValues []string) string {
+	var pairs []string
+	for i, name := range f.labelNames {
+		value := ""
+		if i < len(labelValues) {
+			value = labelValues[i]
+		}
+		pairs = append(pairs, name+"=\""+labelValueReplacer.Replace(value)+"\"")
+	}
+	return strings.Join(pairs, ",")
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WriteTo writes the recorded metrics in the Prometheus text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var buf bytes.Buffer
+	for _, f := range r.families {
+		if len(f.series) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+		labelSets := make([]string, 0, len(f.series))
+		for labels := range f.series {
+			labelSets = append(labelSets, labels)
+		}
+		sort.Strings(labelSets)
+		for _, labels := range labelSets {
+			s := f.series[labels]
+			if f.kind != histogramKind {
+				fmt.Fprintf(&buf, "%s%s %s\n", f.name, braces(labels), formatValue(s.value))
+				continue
+			}
+			for i, bucket := range f.buckets {
+				fmt.Fprintf(&buf, "%s_bucket%s %s\n", f.name, braces(withLabel(labels, "le", formatValue(bucket))),
+					formatValue(s.bucketCounts[i]))
+			}
+			fmt.Fprintf(&buf, "%s_bucket%s %s\n", f.name, braces(withLabel(labels, "le", "+Inf")),
+				formatValue(s.count))
+			fmt.Fprintf(&buf, "%s_sum%s %s\n", f.name, braces(labels), formatValue(s.sum))
+			fmt.Fprintf(&buf, "%s_count%s %s\n", f.name, braces(labels), formatValue(s.count))
+		}
+	}
+	return buf.WriteTo(w)
+}
+
+func braces(labels string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+func withLabel(labels, name, value string) string {
+	pair := name + "=\"" + value + "\""
+	if len(labels) == 0 {
+		return pair
+	}
+	return labels + "," + pair
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// WriteTextfile replaces the node_exporter textfile at path with the total of the metrics it holds, written by earlier
+// processes, and of the registry's. Gauges keep their value from the file unless set by this process. Concurrent
+// writers are serialized through the lock file path.lock, on the platforms supporting flock. The registry itself is
+// left unchanged.
+func (r *Registry) WriteTextfile(path string) error {
+	lock, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	unlock, err := lockFile(lock)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	total := NewRegistry()
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	err = total.parse(content)
+	if err != nil {
+		return fmt.Errorf("could not parse metrics textfile %s: %w", path, err)
+	}
+	r.mu.Lock()
+	total.addRegistry(r)
+	r.mu.Unlock()
+
+	// Write to a temporary file first, not read by node_exporter, so that it never collects a half written file.
+	tmpFile := path + ".tmp"
+	file, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = total.WriteTo(file)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, path)
+}
+
+// addRegistry adds the series of other to the registry's, which must be locked by the caller.
+func (r *Registry) addRegistry(other *Registry) {
+	for i, f := range other.families {
+		total := r.families[i]
+		for labels, s := range f.series {
+			totalSeries := total.get(labels)
+			switch f.kind {
+			case gaugeKind:
+				if s.set {
+					totalSeries.value = s.value
+				}
+			case histogramKind:
+				for j := range s.bucketCounts {
+					totalSeries.bucketCounts[j] += s.bucketCounts[j]
+				}
+				totalSeries.sum += s.sum
+				totalSeries.count += s.count
+			default:
+				totalSeries.value += s.value
+			}
+		}
+	}
+}
+
+// parse adds the samples of metrics in the text exposition format, as written by WriteTo, to the registry. Samples of
+// unknown metrics or histogram buckets are ignored, e.g. after an upgrade changed them.
+func (r *Registry) parse(content []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sample, rawValue, found := strings.Cut(line, " ")
+		if !found {
+			return errors.New("invalid sample: " + line)
+		}
+		value, err := strconv.ParseFloat(rawValue, 64)
+		if err != nil {
+			return err
+		}
+		name, labels, _ := strings.Cut(sample, "{")
+		labels = strings.TrimSuffix(labels, "}")
+		r.addSample(name, labels, value)
+	}
+	return scanner.Err()
+}
+
+func (r *Registry) addSample(name, labels string, value float64) {
+	for _, f := range r.families {
+		if f.kind != histogramKind {
+			if name == f.name {
+				f.get(labels).value += value
+			}
+			continue
+		}
+		switch name {
+		case f.name + "_bucket":
+			le := ""
+			if index := strings.LastIndex(labels, "le=\""); index >= 0 {
+				le = strings.TrimSuffix(labels[index+len("le=\""):], "\"")
+				labels = strings.TrimSuffix(labels[:index], ",")
+			}
+			for i, bucket := range f.buckets {
+				if formatValue(bucket) == le {
+					f.get(labels).bucketCounts[i] += value
+				}
+			}
+		case f.name + "_sum":
+			f.get(labels).sum += value
+		case f.name + "_count":
+			f.get(labels).count += value
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	registry := NewRegistry()
+	var buf bytes.Buffer
+	if _, err := registry.WriteTo(&buf); err != nil || buf.Len() != 0 {
+		t.Fatalf("WriteTo() of an empty registry got %q, %v, want no metric", buf.String(), err)
+	}
+
+	registry.ObserveJob("push", "success", 45*time.Second)
+	registry.ObserveJob("push", "success", 90*time.Second)
+	registry.ObserveJob("patch.updated", "failure", 20*time.Second)
+	registry.ObserveGitHubAPICall("/repos/:owner/:repo/actions/runs", 200)
+	registry.ObserveGitHubAPICall("/repos/:owner/:repo/actions/runs", 200)
+	registry.SetGitHubRateLimitRemaining(4998)
+	registry.ObserveRadicleAction("patch.revision.comment", nil)
+	registry.ObserveRadicleAction("patch.revision.comment", errors.New("unauthorized"))
+	registry.ObserveRadicleAction("issue.comment\"\n", nil)
+	_, err := registry.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	got := buf.String()
+
+	durationSeries := "radicle_github_actions_adapter_job_duration_seconds"
+	expected := []string{
+		"# HELP radicle_github_actions_adapter_jobs_total Broker jobs served, by event kind and result.\n" +
+			"# TYPE radicle_github_actions_adapter_jobs_total counter\n" +
+			"radicle_github_actions_adapter_jobs_total{event=\"patch.updated\",result=\"failure\"} 1\n" +
+			"radicle_github_actions_adapter_jobs_total{event=\"push\",result=\"success\"} 2\n",
+		"# TYPE radicle_github_actions_adapter_job_duration_seconds histogram\n",
+		durationSeries + `_bucket{event="push",result="success",le="30"} 0` + "\n" +
+			durationSeries + `_bucket{event="push",result="success",le="60"} 1` + "\n" +
+			durationSeries + `_bucket{event="push",result="success",le="120"} 2` + "\n",
+		durationSeries + `_bucket{event="push",result="success",le="+Inf"} 2` + "\n" +
+			durationSeries + `_sum{event="push",result="success"} 135` + "\n" +
+			durationSeries + `_count{event="push",result="success"} 2` + "\n",
+		"radicle_github_actions_adapter_github_api_calls_total{endpoint=\"/repos/:owner/:repo/actions/runs\"," +
+			"status_code=\"200\"} 2\n",
+		"# TYPE radicle_github_actions_adapter_github_rate_limit_remaining gauge\n" +
+			"radicle_github_actions_adapter_github_rate_limit_remaining 4998\n",
+		"radicle_github_actions_adapter_radicle_actions_total{action=\"issue.comment\\\"\\n\"} 1\n" +
+			"radicle_github_actions_adapter_radicle_actions_total{action=\"patch.revision.comment\"} 2\n",
+		"radicle_github_actions_adapter_radicle_action_failures_total{action=\"patch.revision.comment\"} 1\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(got, e) {
+			t.Errorf("WriteTo() got %q, want it to contain %q", got, e)
+		}
+	}
+	for _, unexpected := range []string{"clone_duration_seconds", "time_to_first_run_seconds"} {
+		if strings.Contains(got, unexpected) {
+			t.Errorf("WriteTo() got %q, want no %s metric without observations", got, unexpected)
+		}
+	}
+}
+
+func TestRegistry_WriteTextfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "adapter.prom")
+
+	first := NewRegistry()
+	first.ObserveJob("push", "success", 45*time.Second)
+	first.ObserveClone(2 * time.Second)
+	first.SetGitHubRateLimitRemaining(4998)
+	if err := first.WriteTextfile(path); err != nil {
+		t.Fatalf("WriteTextfile() error = %v", err)
+	}
+
+	second := NewRegistry()
+	second.ObserveJob("push", "success", 90*time.Second)
+	second.ObserveJob("patch.created", "error", 5*time.Second)
+	second.ObserveClone(20 * time.Second)
+	second.ObserveTimeToFirstRun(12 * time.Second)
+	if err := second.WriteTextfile(path); err != nil {
+		t.Fatalf("WriteTextfile() error = %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read metrics textfile: %v", err)
+	}
+	expected := NewRegistry()
+	expected.ObserveJob("push", "success", 45*time.Second)
+	expected.ObserveJob("push", "success", 90*time.Second)
+	expected.ObserveJob("patch.created", "error", 5*time.Second)
+	expected.ObserveClone(2 * time.Second)
+	expected.ObserveClone(20 * time.Second)
+	expected.ObserveTimeToFirstRun(12 * time.Second)
+	expected.SetGitHubRateLimitRemaining(4998)
+	var want bytes.Buffer
+	if _, err := expected.WriteTo(&want); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if string(content) != want.String() {
+		t.Errorf("WriteTextfile() got\n%s\nwant\n%s", content, want.String())
+	}
+
+	if err := os.WriteFile(path, []byte("radicle_github_actions_adapter_jobs_total\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.WriteTextfile(path); err == nil {
+		t.Errorf("WriteTextfile() of an invalid textfile expected error")
+	}
+}
@@ -0,0 +1,72 @@
+package progresslog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"radicle-github-actions-adapter/app/broker"
+)
+
+const progressFileSuffix string = ".jsonl"
+
+type ProgressLog struct {
+	directory string
+	logger    *slog.Logger
+}
+
+// NewProgressLog returns a ProgressLog which appends the progress messages of every run as JSON lines to a file
+// under directory.
+func NewProgressLog(directory string, logger *slog.Logger) *ProgressLog {
+	return &ProgressLog{
+		directory: directory,
+		logger:    logger,
+	}
+}
+
+// ReportProgress appends progressMessage to the file of its run.
+func (pl *ProgressLog) ReportProgress(ctx context.Context, progressMessage broker.ProgressMessage) error {
+	if progressMessage.RunID == nil || len(progressMessage.RunID.ID) == 0 {
+		return errors.New("progress message has no run ID")
+	}
+	err := os.MkdirAll(pl.directory, 0o700)
+	if err != nil {
+		pl.logger.Error("could not create progress directory", "directory", pl.directory, "error", err.Error())
+		return err
+	}
+	content, err := json.Marshal(progressMessage)
+	if err != nil {
+		pl.logger.Error("could not encode progress message", "run_id", progressMessage.RunID.ID, "error",
+			err.Error())
+		return err
+	}
+	file, err := os.OpenFile(pl.progressPath(progressMessage.RunID.ID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		pl.logger.Error("could not open progress file", "run_id", progressMessage.RunID.ID, "error", err.Error())
+		return err
+	}
+	_, err = file.Write(append(content, '\n'))
+	if err != nil {
+		_ = file.Close()
+		pl.logger.Error("could not write progress message", "run_id", progressMessage.RunID.ID, "error",
+			err.Error())
+		return err
+	}
+	return file.Close()
+}
+
+// DeleteProgress removes the file of the run, if any.
+func (pl *ProgressLog) DeleteProgress(ctx context.Context, runID string) error {
+	err := os.Remove(pl.progressPath(runID))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		pl.logger.Error("could not delete progress file", "run_id", runID, "error", err.Error())
+		return err
+	}
+	return nil
+}
+
+func (pl *ProgressLog) progressPath(runID string) string {
+	return filepath.Join(pl.directory, filepath.Base(runID)+progressFileSuffix)
+}
@@ -0,0 +1,76 @@
+package progresslog
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"radicle-github-actions-adapter/app/broker"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressLog_ReportProgress(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{}))
+	directory := filepath.Join(t.TempDir(), "progress")
+	pl := NewProgressLog(directory, logger)
+	ctx := context.Background()
+
+	if err := pl.ReportProgress(ctx, broker.ProgressMessage{Response: "progress"}); err == nil {
+		t.Fatalf("ReportProgress() message without run ID expected error")
+	}
+
+	messages := []broker.ProgressMessage{
+		{
+			Response:  "progress",
+			RunID:     &broker.RunID{ID: "run_id"},
+			Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			Workflows: []broker.WorkflowProgress{
+				{WorkflowID: "1", WorkflowName: "build", Status: "queued"},
+			},
+		},
+		{
+			Response:  "progress",
+			RunID:     &broker.RunID{ID: "run_id"},
+			Timestamp: time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC),
+			Workflows: []broker.WorkflowProgress{
+				{WorkflowID: "1", WorkflowName: "build", Status: "success", PreviousStatus: "queued"},
+			},
+		},
+	}
+	for _, message := range messages {
+		if err := pl.ReportProgress(ctx, message); err != nil {
+			t.Fatalf("ReportProgress() error = %v", err)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(directory, "run_id.jsonl"))
+	if err != nil {
+		t.Fatalf("could not read progress file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	if len(lines) != len(messages) {
+		t.Fatalf("progress file got %d lines, want %d", len(lines), len(messages))
+	}
+	for i, line := range lines {
+		got := broker.ProgressMessage{}
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("could not decode progress line %q: %v", line, err)
+		}
+		if !reflect.DeepEqual(got, messages[i]) {
+			t.Errorf("progress line %d got = %+v, want %+v", i, got, messages[i])
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := pl.DeleteProgress(ctx, "run_id"); err != nil {
+			t.Fatalf("DeleteProgress() error = %v", err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(directory, "run_id.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("DeleteProgress() kept the progress file, stat error = %v", err)
+	}
+}
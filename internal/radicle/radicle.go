@@ -4,32 +4,51 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"radicle-github-actions-adapter/app/metrics"
 	"radicle-github-actions-adapter/app/radicle"
 	"strconv"
+	"strings"
 )
 
 const patchURL string = "%s/api/v1/projects/%s/patches/%s"
+const issueURL string = "%s/api/v1/projects/%s/issues/%s"
+const issuesURL string = "%s/api/v1/projects/%s/issues"
+const sessionURL string = "%s/api/v1/sessions/%s"
+
+// commentMarker is a hidden HTML comment added to the adapter's patch comments, identifying the revision they report
+// on, so that the comment can be found and edited again by another adapter process.
+const commentMarker string = "<!-- radicle-github-actions-adapter revision:%s -->"
 
 type Radicle struct {
 	nodeURL   string
 	token     string
 	client    httpClient
 	logger    *slog.Logger
+	metrics   metrics.Metrics
 	commentID *string
 	message   *string
+	// commentFound is whether commentID was found on the revision rather than created by this instance.
+	commentFound bool
+	// nodeDID is the DID of the node whose session the token is, the author of the adapter's comments.
+	nodeDID *string
+	// codeComments are the code comments of the patch revision, by codeCommentKey.
+	codeComments map[string]bool
 }
 
-func NewRadicle(nodeURL, token string, logger *slog.Logger) *Radicle {
+// NewRadicle returns a Radicle client of the radicle-httpd at nodeURL. Its actions are recorded to metrics, if not nil.
+func NewRadicle(nodeURL, token string, logger *slog.Logger, metrics metrics.Metrics) *Radicle {
 	return &Radicle{
 		nodeURL: nodeURL,
 		token:   token,
 		client:  http.DefaultClient,
 		logger:  logger,
+		metrics: metrics,
 	}
 }
 
@@ -37,13 +56,20 @@ type httpClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
-func (r *Radicle) Comment(ctx context.Context, repoID, patchID, revisionID, message string, append bool) error {
+// Comment adds a comment to the patch revision or edits the one previously created by this instance or, through its
+// marker, by an earlier adapter process. A new comment is added if the one of an earlier process cannot be edited. It
+// returns the ID of the comment.
+func (r *Radicle) Comment(ctx context.Context, repoID, patchID, revisionID, message string, append bool) (string,
+	error) {
+	if r.commentID == nil {
+		r.findComment(ctx, repoID, patchID, revisionID)
+	}
 	if append && r.message != nil {
 		message = *r.message + "\n  \n  " + message
 	}
 	payload := radicle.CreatePatchComment{
 		Type:     radicle.CreatePatchCommentType,
-		Body:     message,
+		Body:     withCommentMarker(message, revisionID),
 		Revision: revisionID,
 		Embeds:   []string{},
 	}
@@ -51,7 +77,308 @@ func (r *Radicle) Comment(ctx context.Context, repoID, patchID, revisionID, mess
 		payload.Type = radicle.EditPatchCommentType
 		payload.Comment = r.commentID
 	}
-	createRadiclePatchComment := fmt.Sprintf(patchURL, r.nodeURL, repoID, patchID)
+	commentID, err := r.patchAction(ctx, repoID, patchID, payload)
+	if err != nil && r.commentFound {
+		r.logger.Warn("could not edit existing patch comment, adding a new one", "patch_id", patchID, "comment_id",
+			*r.commentID, "error", err.Error())
+		r.commentID = nil
+		r.commentFound = false
+		payload.Type = radicle.CreatePatchCommentType
+		payload.Comment = nil
+		commentID, err = r.patchAction(ctx, repoID, patchID, payload)
+	}
+	if nil == err && len(commentID) > 0 && r.commentID == nil {
+		r.commentID = &commentID
+	}
+	r.message = &message
+	if r.commentID != nil {
+		return *r.commentID, err
+	}
+	return "", err
+}
+
+// EditComment replaces the body of an existing patch revision comment.
+func (r *Radicle) EditComment(ctx context.Context, repoID, patchID, revisionID, commentID, message string) error {
+	payload := radicle.CreatePatchComment{
+		Type:     radicle.EditPatchCommentType,
+		Body:     withCommentMarker(message, revisionID),
+		Revision: revisionID,
+		Comment:  &commentID,
+		Embeds:   []string{},
+	}
+	_, err := r.patchAction(ctx, repoID, patchID, payload)
+	return err
+}
+
+// RedactComment removes an existing patch revision comment.
+func (r *Radicle) RedactComment(ctx context.Context, repoID, patchID, revisionID, commentID string) error {
+	payload := radicle.RedactPatchComment{
+		Type:     radicle.RedactPatchCommentType,
+		Revision: revisionID,
+		Comment:  commentID,
+	}
+	_, err := r.patchAction(ctx, repoID, patchID, payload)
+	return err
+}
+
+// CommentCode adds a comment to the patch revision anchored to location, unless the revision already has the same
+// comment at the same location. It returns the ID of the comment, empty when it already existed.
+func (r *Radicle) CommentCode(ctx context.Context, repoID, patchID, revisionID string, location radicle.CodeLocation,
+	message string) (string, error) {
+	if r.codeComments == nil {
+		comments, err := r.revisionComments(ctx, repoID, patchID, revisionID)
+		if err != nil {
+			return "", err
+		}
+		r.codeComments = map[string]bool{}
+		for _, comment := range comments {
+			if comment.Location != nil {
+				r.codeComments[codeCommentKey(*comment.Location, comment.Body)] = true
+			}
+		}
+	}
+	if r.codeComments[codeCommentKey(location, message)] {
+		r.logger.Debug("code comment already exists", "patch_id", patchID, "path", location.Path)
+		return "", nil
+	}
+	payload := radicle.CreatePatchComment{
+		Type:     radicle.CreatePatchCommentType,
+		Body:     message,
+		Revision: revisionID,
+		Location: &location,
+		Embeds:   []string{},
+	}
+	commentID, err := r.patchAction(ctx, repoID, patchID, payload)
+	if err != nil {
+		return "", err
+	}
+	r.codeComments[codeCommentKey(location, message)] = true
+	return commentID, nil
+}
+
+// codeCommentKey identifies a code comment by its file, lines and body.
+func codeCommentKey(location radicle.CodeLocation, body string) string {
+	key := location.Path
+	if location.New != nil {
+		key += fmt.Sprintf(":%d-%d", location.New.Range.Start, location.New.Range.End)
+	}
+	return key + "\n" + body
+}
+
+// revisionComment is a comment of a patch revision as returned by httpd.
+type revisionComment struct {
+	ID     string `json:"id"`
+	Author struct {
+		ID string `json:"id"`
+	} `json:"author"`
+	Body     string                `json:"body"`
+	Location *radicle.CodeLocation `json:"location"`
+}
+
+// revisionComments returns the comments of the patch revision.
+func (r *Radicle) revisionComments(ctx context.Context, repoID, patchID, revisionID string) ([]revisionComment,
+	error) {
+	type patchResp struct {
+		Revisions []struct {
+			ID          string            `json:"id"`
+			Discussions []revisionComment `json:"discussions"`
+		} `json:"revisions"`
+	}
+	headers := map[string]string{}
+	headers["Authorization"] = "Bearer " + r.token
+	patch := &patchResp{}
+	err := r.request(ctx, fmt.Sprintf(patchURL, r.nodeURL, repoID, patchID), http.MethodGet, headers, nil, patch)
+	if err != nil {
+		r.logger.Warn("could not fetch patch comments", "patch_id", patchID, "error", err.Error())
+		return nil, err
+	}
+	for _, revision := range patch.Revisions {
+		if revision.ID == revisionID {
+			return revision.Discussions, nil
+		}
+	}
+	return nil, nil
+}
+
+// findComment looks up the adapter's comment on the patch revision by its marker and its author, the node of the
+// session, and remembers it, so that it is edited instead of duplicated. Failures are only logged and a new comment
+// is created.
+func (r *Radicle) findComment(ctx context.Context, repoID, patchID, revisionID string) {
+	comments, err := r.revisionComments(ctx, repoID, patchID, revisionID)
+	if err != nil {
+		return
+	}
+	marker := fmt.Sprintf(commentMarker, revisionID)
+	for _, comment := range comments {
+		message, found := strings.CutSuffix(comment.Body, "\n"+marker)
+		if !found {
+			continue
+		}
+		did, err := r.getNodeDID(ctx)
+		if err != nil {
+			return
+		}
+		if comment.Author.ID != did {
+			r.logger.Debug("skipping patch comment of another author", "patch_id", patchID, "comment_id",
+				comment.ID, "author", comment.Author.ID)
+			continue
+		}
+		r.logger.Debug("found existing patch comment", "patch_id", patchID, "comment_id", comment.ID)
+		commentID := comment.ID
+		r.commentID = &commentID
+		r.commentFound = true
+		r.message = &message
+		return
+	}
+}
+
+// getNodeDID returns the DID of the node whose session the token is, fetched once.
+func (r *Radicle) getNodeDID(ctx context.Context) (string, error) {
+	if r.nodeDID != nil {
+		return *r.nodeDID, nil
+	}
+	type sessionResp struct {
+		PublicKey string `json:"publicKey"`
+	}
+	headers := map[string]string{}
+	headers["Authorization"] = "Bearer " + r.token
+	session := &sessionResp{}
+	err := r.request(ctx, fmt.Sprintf(sessionURL, r.nodeURL, r.token), http.MethodGet, headers, nil, session)
+	if err == nil && len(session.PublicKey) == 0 {
+		err = errors.New("session has no public key")
+	}
+	if err != nil {
+		r.logger.Warn("could not fetch session", "error", err.Error())
+		return "", err
+	}
+	did := "did:key:" + session.PublicKey
+	r.nodeDID = &did
+	return did, nil
+}
+
+// withCommentMarker appends the hidden marker of the revision to a patch comment's message.
+func withCommentMarker(message, revisionID string) string {
+	return message + "\n" + fmt.Sprintf(commentMarker, revisionID)
+}
+
+// SetLabels replaces the labels of the patch.
+func (r *Radicle) SetLabels(ctx context.Context, repoID, patchID string, labels []string) error {
+	payload := radicle.LabelPatch{
+		Type:   radicle.LabelPatchType,
+		Labels: labels,
+	}
+	_, err := r.patchAction(ctx, repoID, patchID, payload)
+	return err
+}
+
+// CommentIssue adds a comment to the issue or edits the one previously created by this instance.
+// It returns the ID of the comment.
+func (r *Radicle) CommentIssue(ctx context.Context, repoID, issueID, message string, append bool) (string, error) {
+	if append && r.message != nil {
+		message = *r.message + "\n  \n  " + message
+	}
+	var payload any = radicle.CreateIssueComment{
+		Type:    radicle.CreateIssueCommentType,
+		Body:    message,
+		ReplyTo: issueID,
+		Embeds:  []string{},
+	}
+	if r.commentID != nil {
+		payload = radicle.EditIssueComment{
+			Type:   radicle.EditIssueCommentType,
+			ID:     *r.commentID,
+			Body:   message,
+			Embeds: []string{},
+		}
+	}
+	commentID, err := r.comment(ctx, fmt.Sprintf(issueURL, r.nodeURL, repoID, issueID), payload)
+	if nil == err && len(commentID) > 0 && r.commentID == nil {
+		r.commentID = &commentID
+	}
+	r.message = &message
+	if r.commentID != nil {
+		return *r.commentID, err
+	}
+	return "", err
+}
+
+// EditIssueComment replaces the body of an existing issue comment.
+func (r *Radicle) EditIssueComment(ctx context.Context, repoID, issueID, commentID, message string) error {
+	payload := radicle.EditIssueComment{
+		Type:   radicle.EditIssueCommentType,
+		ID:     commentID,
+		Body:   message,
+		Embeds: []string{},
+	}
+	_, err := r.comment(ctx, fmt.Sprintf(issueURL, r.nodeURL, repoID, issueID), payload)
+	return err
+}
+
+// AddIssueComment adds a new comment to the issue, independently of the comment managed by CommentIssue.
+// It returns the ID of the comment.
+func (r *Radicle) AddIssueComment(ctx context.Context, repoID, issueID, message string) (string, error) {
+	payload := radicle.CreateIssueComment{
+		Type:    radicle.CreateIssueCommentType,
+		Body:    message,
+		ReplyTo: issueID,
+		Embeds:  []string{},
+	}
+	return r.comment(ctx, fmt.Sprintf(issueURL, r.nodeURL, repoID, issueID), payload)
+}
+
+// CreateIssue opens a new issue in the repo and returns its ID.
+func (r *Radicle) CreateIssue(ctx context.Context, repoID, title, description string) (string, error) {
+	payload := radicle.CreateIssue{
+		Title:       title,
+		Description: description,
+		Labels:      []string{},
+		Assignees:   []string{},
+		Embeds:      []string{},
+	}
+	return r.cobAction(ctx, fmt.Sprintf(issuesURL, r.nodeURL, repoID), http.MethodPost, payload)
+}
+
+// SetIssueOpen reopens the issue or closes it as solved.
+func (r *Radicle) SetIssueOpen(ctx context.Context, repoID, issueID string, open bool) error {
+	payload := radicle.IssueLifecycle{
+		Type:  radicle.IssueLifecycleType,
+		State: radicle.IssueState{Status: radicle.IssueStatusClosed, Reason: radicle.IssueReasonSolved},
+	}
+	if open {
+		payload.State = radicle.IssueState{Status: radicle.IssueStatusOpen}
+	}
+	_, err := r.comment(ctx, fmt.Sprintf(issueURL, r.nodeURL, repoID, issueID), payload)
+	return err
+}
+
+// IssueOpen reports whether the issue is currently open.
+func (r *Radicle) IssueOpen(ctx context.Context, repoID, issueID string) (bool, error) {
+	type issueResp struct {
+		State radicle.IssueState `json:"state"`
+	}
+	headers := map[string]string{}
+	headers["Authorization"] = "Bearer " + r.token
+	issue := &issueResp{}
+	err := r.request(ctx, fmt.Sprintf(issueURL, r.nodeURL, repoID, issueID), http.MethodGet, headers, nil, issue)
+	if err != nil {
+		r.logger.Warn("could not fetch issue", "issue_id", issueID, "error", err.Error())
+		return false, err
+	}
+	return issue.State.Status == radicle.IssueStatusOpen, nil
+}
+
+// patchAction sends an action to the patch and returns the ID in the response, if any.
+func (r *Radicle) patchAction(ctx context.Context, repoID, patchID string, payload any) (string, error) {
+	return r.comment(ctx, fmt.Sprintf(patchURL, r.nodeURL, repoID, patchID), payload)
+}
+
+// comment sends a comment action to the COB at cobURL and returns the ID of the comment, if any.
+func (r *Radicle) comment(ctx context.Context, cobURL string, payload any) (string, error) {
+	return r.cobAction(ctx, cobURL, http.MethodPatch, payload)
+}
+
+// cobAction sends payload to cobURL and returns the ID in the response, if any.
+func (r *Radicle) cobAction(ctx context.Context, cobURL, method string, payload any) (string, error) {
 	headers := map[string]string{}
 	headers["content-type"] = "application/json"
 	headers["Authorization"] = "Bearer " + r.token
@@ -60,12 +387,36 @@ func (r *Radicle) Comment(ctx context.Context, repoID, patchID, revisionID, mess
 		Id      string `json:"id"`
 	}
 	resp := &commentAddResp{}
-	err := r.request(ctx, createRadiclePatchComment, http.MethodPatch, headers, payload, resp)
-	if nil == err && len(resp.Id) > 0 && r.commentID == nil {
-		r.commentID = &resp.Id
+	err := r.request(ctx, cobURL, method, headers, payload, resp)
+	if r.metrics != nil {
+		r.metrics.ObserveRadicleAction(payloadAction(payload), err)
+	}
+	if err != nil {
+		return "", err
+	}
+	return resp.Id, nil
+}
+
+// payloadAction returns the COB and action of payload, e.g. patch.revision.comment, to record it in the metrics.
+func payloadAction(payload any) string {
+	switch p := payload.(type) {
+	case radicle.CreatePatchComment:
+		return "patch." + p.Type
+	case radicle.RedactPatchComment:
+		return "patch." + p.Type
+	case radicle.LabelPatch:
+		return "patch." + p.Type
+	case radicle.CreateIssueComment:
+		return "issue." + p.Type
+	case radicle.EditIssueComment:
+		return "issue." + p.Type
+	case radicle.IssueLifecycle:
+		return "issue." + p.Type
+	case radicle.CreateIssue:
+		return "issue.create"
+	default:
+		return "unknown"
 	}
-	r.message = &message
-	return err
 }
 
 type HttpError struct {
@@ -7,8 +7,12 @@ import (
 	"net/http"
 	"os"
 	"radicle-github-actions-adapter/app"
+	"radicle-github-actions-adapter/app/radicle"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 type MockHTTPClient struct {
@@ -143,7 +147,16 @@ func TestRadicle_Comment(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockClient := MockHTTPClient{
-				DoFunc: tt.fields.clientDoFunc,
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					// The patch is fetched first to find an existing comment.
+					if req.Method == http.MethodGet {
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(strings.NewReader(`{"revisions":[]}`)),
+						}, nil
+					}
+					return tt.fields.clientDoFunc(req)
+				},
 			}
 			r := &Radicle{
 				nodeURL: tt.fields.nodeURL,
@@ -151,10 +164,398 @@ func TestRadicle_Comment(t *testing.T) {
 				client:  &mockClient,
 				logger:  tt.fields.logger,
 			}
-			if err := r.Comment(tt.args.ctx, tt.args.repoID, tt.args.patchID, tt.args.revisionID,
+			if _, err := r.Comment(tt.args.ctx, tt.args.repoID, tt.args.patchID, tt.args.revisionID,
 				tt.args.message, false); (err != nil) != tt.wantErr {
 				t.Errorf("Comment() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func TestRadicle_RedactComment(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{}))
+	r := &Radicle{
+		nodeURL: "http://node.url",
+		token:   "some_token",
+		logger:  logger,
+		client: &MockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Errorf("Redact patch comment could not read request body %v", err)
+			}
+			want := `{"type":"revision.comment.redact","revision":"revision_id","comment":"comment_id"}` + "\n"
+			if string(body) != want {
+				t.Errorf("Redact patch comment request payload got = %v, want %v", string(body), want)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"success":true}`)),
+			}, nil
+		}},
+	}
+	err := r.RedactComment(context.Background(), "repo_id", "patch_id", "revision_id", "comment_id")
+	if err != nil {
+		t.Errorf("RedactComment() error = %v", err)
+	}
+}
+
+func TestRadicle_CommentIssue(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{}))
+	var payloads []string
+	r := &Radicle{
+		nodeURL: "http://node.url",
+		token:   "some_token",
+		logger:  logger,
+		client: &MockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+			if "http://node.url/api/v1/projects/repo_id/issues/issue_id" != req.URL.String() {
+				t.Errorf("Issue comment request URL got = %v, want %v", req.URL.String(),
+					"http://node.url/api/v1/projects/repo_id/issues/issue_id")
+			}
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Errorf("Issue comment could not read request body %v", err)
+			}
+			payloads = append(payloads, string(body))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"success":true,"id":"comment_id"}`)),
+			}, nil
+		}},
+	}
+	for _, message := range []string{"checking", "done"} {
+		commentID, err := r.CommentIssue(context.Background(), "repo_id", "issue_id", message, false)
+		if err != nil || commentID != "comment_id" {
+			t.Fatalf("CommentIssue() got = %v, %v, want comment_id", commentID, err)
+		}
+	}
+	want := []string{
+		`{"type":"comment","body":"checking","replyTo":"issue_id","embeds":[]}` + "\n",
+		`{"type":"comment.edit","id":"comment_id","body":"done","embeds":[]}` + "\n",
+	}
+	if !reflect.DeepEqual(payloads, want) {
+		t.Errorf("CommentIssue() request payloads got = %v, want %v", payloads, want)
+	}
+}
+
+func TestRadicle_IssueLifecycle(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{}))
+	var requests []string
+	r := &Radicle{
+		nodeURL: "http://node.url",
+		token:   "some_token",
+		logger:  logger,
+		client: &MockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Errorf("Issue request could not read request body %v", err)
+			}
+			requests = append(requests, req.Method+" "+req.URL.String()+" "+string(body))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"success":true,"id":"issue_id"}`)),
+			}, nil
+		}},
+	}
+	ctx := context.Background()
+	issueID, err := r.CreateIssue(ctx, "repo_id", "CI failing on main", "details")
+	if err != nil || issueID != "issue_id" {
+		t.Fatalf("CreateIssue() got = %v, %v, want issue_id", issueID, err)
+	}
+	if err := r.SetIssueOpen(ctx, "repo_id", "issue_id", false); err != nil {
+		t.Fatalf("SetIssueOpen() error = %v", err)
+	}
+	if err := r.SetIssueOpen(ctx, "repo_id", "issue_id", true); err != nil {
+		t.Fatalf("SetIssueOpen() error = %v", err)
+	}
+	want := []string{
+		`POST http://node.url/api/v1/projects/repo_id/issues {"title":"CI failing on main","description":"details",` +
+			`"labels":[],"assignees":[],"embeds":[]}` + "\n",
+		`PATCH http://node.url/api/v1/projects/repo_id/issues/issue_id {"type":"lifecycle",` +
+			`"state":{"status":"closed","reason":"solved"}}` + "\n",
+		`PATCH http://node.url/api/v1/projects/repo_id/issues/issue_id {"type":"lifecycle",` +
+			`"state":{"status":"open"}}` + "\n",
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("issue requests got = %v, want %v", requests, want)
+	}
+}
+
+func TestRadicle_IssueOpen(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{}))
+	states := map[string]string{
+		"open_issue_id":   `{"id":"open_issue_id","state":{"status":"open"}}`,
+		"closed_issue_id": `{"id":"closed_issue_id","state":{"status":"closed","reason":"solved"}}`,
+	}
+	r := &Radicle{
+		nodeURL: "http://node.url",
+		token:   "some_token",
+		logger:  logger,
+		client: &MockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+			issueID := strings.TrimPrefix(req.URL.Path, "/api/v1/projects/repo_id/issues/")
+			state, found := states[issueID]
+			if req.Method != http.MethodGet || !found {
+				return &http.Response{
+					StatusCode: http.StatusNotFound,
+					Body:       io.NopCloser(strings.NewReader(`"Not Found"`)),
+				}, nil
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(state))}, nil
+		}},
+	}
+	ctx := context.Background()
+	if open, err := r.IssueOpen(ctx, "repo_id", "open_issue_id"); err != nil || !open {
+		t.Errorf("IssueOpen() got = %v, %v, want open", open, err)
+	}
+	if open, err := r.IssueOpen(ctx, "repo_id", "closed_issue_id"); err != nil || open {
+		t.Errorf("IssueOpen() got = %v, %v, want closed", open, err)
+	}
+	if _, err := r.IssueOpen(ctx, "repo_id", "unknown_issue_id"); err == nil {
+		t.Errorf("IssueOpen() of an unknown issue expected error")
+	}
+}
+
+func TestRadicle_SetLabels(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{}))
+	r := &Radicle{
+		nodeURL: "http://node.url",
+		token:   "some_token",
+		logger:  logger,
+		client: &MockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Errorf("Label patch could not read request body %v", err)
+			}
+			want := `{"type":"label","labels":["bug","ci:passing"]}` + "\n"
+			if string(body) != want {
+				t.Errorf("Label patch request payload got = %v, want %v", string(body), want)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"success":true}`)),
+			}, nil
+		}},
+	}
+	err := r.SetLabels(context.Background(), "repo_id", "patch_id", []string{"bug", "ci:passing"})
+	if err != nil {
+		t.Errorf("SetLabels() error = %v", err)
+	}
+}
+
+func TestRadicle_CommentFindsExistingComment(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{}))
+	var payloads []string
+	r := &Radicle{
+		nodeURL: "http://node.url",
+		token:   "some_token",
+		logger:  logger,
+		client: &MockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+			if req.Method == http.MethodGet {
+				return revisionCommentsResponse(req), nil
+			}
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Errorf("Comment could not read request body %v", err)
+			}
+			payloads = append(payloads, string(body))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"success":true}`)),
+			}, nil
+		}},
+	}
+	commentID, err := r.Comment(context.Background(), "repo_id", "patch_id", "revision_id", "failed", true)
+	if err != nil || commentID != "comment_id" {
+		t.Fatalf("Comment() got = %v, %v, want comment_id", commentID, err)
+	}
+	want := []string{`{"type":"revision.comment.edit","body":"Checking for GitHub Actions Workflows...\n  \n  failed` +
+		`\n\u003c!-- radicle-github-actions-adapter revision:revision_id --\u003e","revision":"revision_id",` +
+		`"comment":"comment_id","embeds":[]}` + "\n"}
+	if !reflect.DeepEqual(payloads, want) {
+		t.Errorf("Comment() request payloads got = %v, want %v", payloads, want)
+	}
+}
+
+func TestRadicle_CommentAddsNewCommentWhenEditFails(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{}))
+	var payloads []string
+	r := &Radicle{
+		nodeURL: "http://node.url",
+		token:   "some_token",
+		logger:  logger,
+		client: &MockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+			if req.Method == http.MethodGet {
+				return revisionCommentsResponse(req), nil
+			}
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Errorf("Comment could not read request body %v", err)
+			}
+			payloads = append(payloads, string(body))
+			if strings.Contains(string(body), "revision.comment.edit") {
+				return &http.Response{
+					StatusCode: http.StatusNotFound,
+					Body:       io.NopCloser(strings.NewReader(`{"error":{"message":"comment not found"}}`)),
+				}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"success":true,"id":"new_comment_id"}`)),
+			}, nil
+		}},
+	}
+	commentID, err := r.Comment(context.Background(), "repo_id", "patch_id", "revision_id", "failed", false)
+	if err != nil || commentID != "new_comment_id" {
+		t.Fatalf("Comment() got = %v, %v, want new_comment_id", commentID, err)
+	}
+	if len(payloads) != 2 || !strings.Contains(payloads[1], `"type":"revision.comment"`) ||
+		strings.Contains(payloads[1], `"comment":`) {
+		t.Errorf("Comment() request payloads got = %v, want an edit then a new comment", payloads)
+	}
+}
+
+// revisionCommentsResponse responds to the GET requests of a patch with an adapter's comment on revision_id, a
+// comment with the same marker by another author, and to those of the session with the node's public key.
+func revisionCommentsResponse(req *http.Request) *http.Response {
+	body := `{"revisions":[
+		{"id":"other_revision","discussions":[{"id":"other_comment","author":{"id":"did:key:z6MkNode"},` +
+		`"body":"Checking for GitHub Actions Workflows...\n` +
+		`<!-- radicle-github-actions-adapter revision:other_revision -->"}]},
+		{"id":"revision_id","discussions":[{"id":"user_comment","author":{"id":"did:key:z6MkUser"},"body":"LGTM"},
+		{"id":"spoofed_comment","author":{"id":"did:key:z6MkUser"},"body":"All good\n` +
+		`<!-- radicle-github-actions-adapter revision:revision_id -->"},
+		{"id":"comment_id","author":{"id":"did:key:z6MkNode"},"body":"Checking for GitHub Actions Workflows...\n` +
+		`<!-- radicle-github-actions-adapter revision:revision_id -->"}]}]}`
+	if strings.HasPrefix(req.URL.Path, "/api/v1/sessions/") {
+		body = `{"sessionId":"some_token","status":"authorized","publicKey":"z6MkNode"}`
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestRadicle_CommentCode(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{}))
+	var payloads []string
+	gets := 0
+	r := &Radicle{
+		nodeURL: "http://node.url",
+		token:   "some_token",
+		logger:  logger,
+		client: &MockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+			if req.Method == http.MethodGet {
+				gets++
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body: io.NopCloser(strings.NewReader(`{"revisions":[{"id":"revision_id","discussions":[
+						{"id":"user_comment","body":"LGTM"},
+						{"id":"code_comment","body":"unused variable","location":{"commit":"commit_id",` +
+						`"path":"main.go","old":null,"new":{"type":"lines","range":{"start":3,"end":4}}}}]}]}`)),
+				}, nil
+			}
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Errorf("CommentCode could not read request body %v", err)
+			}
+			payloads = append(payloads, string(body))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"success":true,"id":"new_comment"}`)),
+			}, nil
+		}},
+	}
+	location := func(start int) radicle.CodeLocation {
+		return radicle.CodeLocation{Commit: "commit_id", Path: "main.go", New: &radicle.CodeRange{
+			Type: radicle.CodeRangeLinesType, Range: radicle.LineRange{Start: start, End: start + 1}}}
+	}
+	tests := []struct {
+		name      string
+		location  radicle.CodeLocation
+		message   string
+		commentID string
+	}{
+		{
+			name:     "CommentCode skips an existing comment",
+			location: location(3),
+			message:  "unused variable",
+		},
+		{
+			name:      "CommentCode adds a comment on other lines",
+			location:  location(7),
+			message:   "unused variable",
+			commentID: "new_comment",
+		},
+		{
+			name:     "CommentCode skips a comment it added",
+			location: location(7),
+			message:  "unused variable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commentID, err := r.CommentCode(context.Background(), "repo_id", "patch_id", "revision_id", tt.location,
+				tt.message)
+			if err != nil || commentID != tt.commentID {
+				t.Errorf("CommentCode() got = %v, %v, want %v", commentID, err, tt.commentID)
+			}
+		})
+	}
+	want := []string{`{"type":"revision.comment","body":"unused variable","revision":"revision_id","location":` +
+		`{"commit":"commit_id","path":"main.go","old":null,"new":{"type":"lines","range":{"start":7,"end":8}}},` +
+		`"embeds":[]}` + "\n"}
+	if !reflect.DeepEqual(payloads, want) || gets != 1 {
+		t.Errorf("CommentCode() request payloads got = %v (%d fetches), want %v", payloads, gets, want)
+	}
+}
+
+type MockMetrics struct {
+	actions []string
+}
+
+func (m *MockMetrics) ObserveJob(event, result string, duration time.Duration) {}
+
+func (m *MockMetrics) ObserveTimeToFirstRun(duration time.Duration) {}
+
+func (m *MockMetrics) ObserveGitHubAPICall(endpoint string, statusCode int) {}
+
+func (m *MockMetrics) SetGitHubRateLimitRemaining(remaining int) {}
+
+func (m *MockMetrics) ObserveRadicleAction(action string, err error) {
+	m.actions = append(m.actions, action+" "+strconv.FormatBool(err != nil))
+}
+
+func (m *MockMetrics) ObserveClone(duration time.Duration) {}
+
+func TestRadicle_ObservesActions(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{}))
+	mockMetrics := &MockMetrics{}
+	r := &Radicle{
+		nodeURL: "http://node.url",
+		token:   "some_token",
+		logger:  logger,
+		metrics: mockMetrics,
+		client: &MockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+			if req.Method == http.MethodPost {
+				return &http.Response{
+					StatusCode: http.StatusUnauthorized,
+					Body:       io.NopCloser(strings.NewReader(`{"error":{"message":"unauthorized"}}`)),
+				}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"success":true}`)),
+			}, nil
+		}},
+	}
+	ctx := context.Background()
+	if err := r.SetLabels(ctx, "repo_id", "patch_id", []string{"ci:passing"}); err != nil {
+		t.Fatalf("SetLabels() error = %v", err)
+	}
+	if _, err := r.CreateIssue(ctx, "repo_id", "CI failing on main", "details"); err == nil {
+		t.Fatalf("CreateIssue() expected error")
+	}
+	if err := r.SetIssueOpen(ctx, "repo_id", "issue_id", false); err != nil {
+		t.Fatalf("SetIssueOpen() error = %v", err)
+	}
+	want := []string{"patch.label false", "issue.create true", "issue.lifecycle false"}
+	if !reflect.DeepEqual(mockMetrics.actions, want) {
+		t.Errorf("observed actions got = %v, want %v", mockMetrics.actions, want)
+	}
+}
@@ -13,6 +13,7 @@ import (
 
 const (
 	radicleGitHubActionsSettingsPath string = "/.radicle/github_actions.yaml"
+	radicleCommentTemplatePath       string = "/.radicle/github_actions_comment.tmpl"
 	gitHubActionsWorkflowsPath       string = "/.github/workflows"
 )
 
@@ -36,6 +37,7 @@ func NewRadicleGitHubActions(radicleHome string, gitOps gitops.GitOps, githubOps
 // GetRepoCommitWorkflowSetup returns the GitHub Actions setup if any.
 // The setup is located at gitHubActionsWorkflowsPath path.
 // Checks also if there are registered workflows under radicleGitHubActionsSettingsPath
+// The comment template at radicleCommentTemplatePath, if any, is part of the setup.
 func (rga *RadicleGitHubActions) GetRepoCommitWorkflowSetup(ctx context.Context, projectID,
 	commitHash string) (*app.GitHubActionsSettings, error) {
 	repoPath := ctx.Value(app.RepoClonePathKey).(string)
@@ -44,7 +46,7 @@ func (rga *RadicleGitHubActions) GetRepoCommitWorkflowSetup(ctx context.Context,
 	defer os.RemoveAll(repoPath)
 
 	rga.logger.Info("cloning project", "ID", projectID, "url", cloneURL, "to", repoPath)
-	err := rga.git.CloneRepoCommit(cloneURL, commitHash, repoPath)
+	err := rga.git.CloneRepoCommit(ctx, cloneURL, commitHash, repoPath)
 	if err != nil {
 		rga.logger.Error("failed to clone repo from URL", "url", cloneURL, "error", err.Error())
 		return nil, err
@@ -66,18 +68,28 @@ func (rga *RadicleGitHubActions) GetRepoCommitWorkflowSetup(ctx context.Context,
 		return nil, nil
 	}
 	rga.logger.Debug(fmt.Sprintf("found GitHub actions workflows yaml files: %+v", githubActionsYamlFilePaths))
+	commentTemplate, err := os.ReadFile(repoPath + radicleCommentTemplatePath)
+	if err == nil {
+		rga.logger.Debug("found comment template", "file", radicleCommentTemplatePath)
+		githubActionsSetup.CommentTemplate = string(commentTemplate)
+	}
 	return githubActionsSetup, nil
 }
 
 // GetRepoCommitWorkflowsResults retrieves the repo's workflows results from GitHub.
+// Only the workflow runs matching filter are returned.
 func (rga *RadicleGitHubActions) GetRepoCommitWorkflowsResults(ctx context.Context, githubUsername, githubRepo,
-	githubCommit string) ([]app.WorkflowResult, error) {
+	githubCommit string, filter app.WorkflowRunsFilter) ([]app.WorkflowResult, error) {
 	err := rga.github.CheckRepoCommit(ctx, githubUsername, githubRepo, githubCommit)
 	if err != nil {
 		rga.logger.Error("no GitHub repo commit found", "error", err.Error())
 		return nil, err
 	}
-	githubWorkflows, err := rga.github.GetRepoCommitWorkflows(ctx, githubUsername, githubRepo, githubCommit)
+	githubWorkflows, err := rga.github.GetRepoCommitWorkflows(ctx, githubUsername, githubRepo, githubCommit,
+		githubops.WorkflowRunsFilter{
+			Events:     filter.Events,
+			HeadBranch: filter.HeadBranch,
+		})
 	if err != nil {
 		rga.logger.Error("could not check for GitHub workflows", "error", err.Error())
 		return nil, err
@@ -93,14 +105,93 @@ func (rga *RadicleGitHubActions) GetRepoCommitWorkflowsResults(ctx context.Conte
 				ApiUrl: artifact.ApiUrl,
 			})
 		}
+		var previousAttempts []app.WorkflowAttempt
+		for _, attempt := range githubWorkflow.PreviousAttempts {
+			previousAttempts = append(previousAttempts, app.WorkflowAttempt{
+				RunID:      attempt.RunID,
+				RunAttempt: attempt.RunAttempt,
+				Status:     attempt.Status,
+				Result:     attempt.Result,
+				Url:        attempt.Url,
+			})
+		}
 		workflows = append(workflows, app.WorkflowResult{
-			WorkflowID:   githubWorkflow.WorkflowID,
-			WorkflowName: githubWorkflow.WorkflowName,
-			Status:       githubWorkflow.Status,
-			Result:       githubWorkflow.Result,
-			Artifacts:    workflowArtifacts,
+			WorkflowID:       githubWorkflow.WorkflowID,
+			WorkflowName:     githubWorkflow.WorkflowName,
+			Status:           githubWorkflow.Status,
+			Result:           githubWorkflow.Result,
+			Event:            githubWorkflow.Event,
+			HeadBranch:       githubWorkflow.HeadBranch,
+			RunNumber:        githubWorkflow.RunNumber,
+			RunAttempt:       githubWorkflow.RunAttempt,
+			CreatedAt:        githubWorkflow.CreatedAt,
+			StartedAt:        githubWorkflow.StartedAt,
+			UpdatedAt:        githubWorkflow.UpdatedAt,
+			HTMLURL:          githubWorkflow.HTMLURL,
+			BillableMS:       githubWorkflow.BillableMS,
+			Artifacts:        workflowArtifacts,
+			PreviousAttempts: previousAttempts,
 		})
 	}
 	rga.logger.Debug(fmt.Sprintf("found GitHub actions workflows: %+v", workflows))
 	return workflows, nil
 }
+
+// GetWorkflowRunAnnotations retrieves the annotations of the check runs of a workflow run from GitHub.
+func (rga *RadicleGitHubActions) GetWorkflowRunAnnotations(ctx context.Context, githubUsername, githubRepo,
+	runID string) ([]app.WorkflowAnnotation, error) {
+	githubAnnotations, err := rga.github.GetWorkflowRunAnnotations(ctx, githubUsername, githubRepo, runID)
+	if err != nil {
+		rga.logger.Error("could not get GitHub workflow run annotations", "run_id", runID, "error", err.Error())
+		return nil, err
+	}
+	var annotations []app.WorkflowAnnotation
+	for _, annotation := range githubAnnotations {
+		annotations = append(annotations, app.WorkflowAnnotation{
+			Path:      annotation.Path,
+			StartLine: annotation.StartLine,
+			EndLine:   annotation.EndLine,
+			Level:     annotation.Level,
+			Title:     annotation.Title,
+			Message:   annotation.Message,
+		})
+	}
+	return annotations, nil
+}
+
+// GetArtifactTestSummary retrieves the summary of the JUnit test reports in an artifact from GitHub.
+func (rga *RadicleGitHubActions) GetArtifactTestSummary(ctx context.Context, githubUsername, githubRepo,
+	artifactID string) (*app.TestSummary, error) {
+	githubSummary, err := rga.github.GetArtifactTestSummary(ctx, githubUsername, githubRepo, artifactID)
+	if err != nil {
+		rga.logger.Error("could not get GitHub artifact test summary", "artifact_id", artifactID, "error",
+			err.Error())
+		return nil, err
+	}
+	summary := &app.TestSummary{
+		Total:   githubSummary.Total,
+		Passed:  githubSummary.Passed,
+		Failed:  githubSummary.Failed,
+		Skipped: githubSummary.Skipped,
+		Flaky:   githubSummary.Flaky,
+	}
+	for _, failedTest := range githubSummary.FailedTests {
+		summary.FailedTests = append(summary.FailedTests, app.FailedTest{
+			Name:    failedTest.Name,
+			Message: failedTest.Message,
+			Flaky:   failedTest.Flaky,
+		})
+	}
+	return summary, nil
+}
+
+// GetArtifactCoverage retrieves the coverage of the coverage reports in an artifact from GitHub.
+func (rga *RadicleGitHubActions) GetArtifactCoverage(ctx context.Context, githubUsername, githubRepo,
+	artifactID string) (*app.Coverage, error) {
+	githubCoverage, err := rga.github.GetArtifactCoverage(ctx, githubUsername, githubRepo, artifactID)
+	if err != nil {
+		rga.logger.Error("could not get GitHub artifact coverage", "artifact_id", artifactID, "error", err.Error())
+		return nil, err
+	}
+	return &app.Coverage{Covered: githubCoverage.Covered, Total: githubCoverage.Total}, nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"radicle-github-actions-adapter/app"
 	"radicle-github-actions-adapter/app/githubops"
 	"radicle-github-actions-adapter/app/gitops"
@@ -15,7 +16,7 @@ import (
 
 type MockGitOps struct{}
 
-func (mgo *MockGitOps) CloneRepoCommit(url, commitHash, repoPath string) error {
+func (mgo *MockGitOps) CloneRepoCommit(ctx context.Context, url, commitHash, repoPath string) error {
 	if !strings.Contains(url, "project_id") || repoPath != "/tmp/some_repo_path" || commitHash != "commit_id" {
 		return errors.New("invalid params")
 	}
@@ -32,7 +33,7 @@ func (mgho *MockGitHubOps) CheckRepoCommit(ctx context.Context, user, repo, comm
 }
 
 func (mgho *MockGitHubOps) GetRepoCommitWorkflows(ctx context.Context, user, repo,
-	commit string) ([]githubops.WorkflowResult, error) {
+	commit string, filter githubops.WorkflowRunsFilter) ([]githubops.WorkflowResult, error) {
 	if user != "gh_username" || repo != "gh_reponame" || commit != "commit_id" {
 		return nil, errors.New("invalid params")
 	}
@@ -53,6 +54,35 @@ func (mgho *MockGitHubOps) GetRepoCommitWorkflows(ctx context.Context, user, rep
 	return result, nil
 }
 
+func (mgho *MockGitHubOps) GetWorkflowRunAnnotations(ctx context.Context, user, repo,
+	runID string) ([]githubops.WorkflowAnnotation, error) {
+	if user != "gh_username" || repo != "gh_reponame" || runID != "work_1" {
+		return nil, errors.New("invalid params")
+	}
+	return []githubops.WorkflowAnnotation{
+		{Path: "main.go", StartLine: 3, EndLine: 4, Level: "failure", Title: "lint", Message: "unused variable"},
+	}, nil
+}
+
+func (mgho *MockGitHubOps) GetArtifactTestSummary(ctx context.Context, user, repo,
+	artifactID string) (*githubops.TestSummary, error) {
+	if user != "gh_username" || repo != "gh_reponame" || artifactID != "artifact_1" {
+		return nil, errors.New("invalid params")
+	}
+	return &githubops.TestSummary{Total: 3, Passed: 2, Failed: 1, Flaky: 1, FailedTests: []githubops.FailedTest{
+		{Name: "TestFail", Message: "boom"},
+		{Name: "TestFlaky", Message: "timeout", Flaky: true},
+	}}, nil
+}
+
+func (mgho *MockGitHubOps) GetArtifactCoverage(ctx context.Context, user, repo,
+	artifactID string) (*githubops.Coverage, error) {
+	if user != "gh_username" || repo != "gh_reponame" || artifactID != "artifact_1" {
+		return nil, errors.New("invalid params")
+	}
+	return &githubops.Coverage{Covered: 80, Total: 100}, nil
+}
+
 func TestRadicleGitHubActions_GetRepoCommitWorkflowSetup(t *testing.T) {
 	mockGitOps := MockGitOps{}
 	mockGitHubOps := MockGitHubOps{}
@@ -278,6 +308,45 @@ func TestRadicleGitHubActions_GetRepoCommitWorkflowSetup(t *testing.T) {
 			want:    nil,
 			wantErr: false,
 		},
+		{
+			name: "GetRepoCommitWorkflowSetup returns the repo's comment template",
+			fields: fields{
+				logger:      logger,
+				radicleHome: "/home/user",
+				git:         &mockGitOps,
+				github:      &mockGitHubOps,
+			},
+			prepareFunc: func() error {
+				files := map[string]string{
+					"/tmp/some_repo_path/.github/workflows/some_workflow.yaml": "",
+					"/tmp/some_repo_path/.radicle/github_actions.yaml": "github_username: gh_username\n" +
+						"github_repo: gh_reponame",
+					"/tmp/some_repo_path/.radicle/github_actions_comment.tmpl": "CI: {{.Result}}",
+				}
+				for path, content := range files {
+					err := os.MkdirAll(filepath.Dir(path), 0700)
+					if err == nil {
+						err = os.WriteFile(path, []byte(content), 0600)
+					}
+					if err != nil {
+						t.Errorf("GetRepoCommitWorkflowSetup() could not prepare test, error = %v", err)
+						return err
+					}
+				}
+				return nil
+			},
+			args: args{
+				ctx:        ctx,
+				projectID:  "project_id",
+				commitHash: "commit_id",
+			},
+			want: &app.GitHubActionsSettings{
+				GitHubUsername:  "gh_username",
+				GitHubRepo:      "gh_reponame",
+				CommentTemplate: "CI: {{.Result}}",
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -403,7 +472,8 @@ func TestRadicleGitHubActions_GetRepoCommitWorkflowsResults(t *testing.T) {
 				git:         tt.fields.git,
 				github:      tt.fields.github,
 			}
-			got, err := rga.GetRepoCommitWorkflowsResults(tt.args.ctx, tt.args.githubUsername, tt.args.githubRepo, tt.args.githubCommit)
+			got, err := rga.GetRepoCommitWorkflowsResults(tt.args.ctx, tt.args.githubUsername, tt.args.githubRepo,
+				tt.args.githubCommit, app.WorkflowRunsFilter{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetRepoCommitWorkflowsResults() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -414,3 +484,120 @@ func TestRadicleGitHubActions_GetRepoCommitWorkflowsResults(t *testing.T) {
 		})
 	}
 }
+
+func TestRadicleGitHubActions_GetWorkflowRunAnnotations(t *testing.T) {
+	rga := &RadicleGitHubActions{
+		logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+		git:    &MockGitOps{},
+		github: &MockGitHubOps{},
+	}
+	tests := []struct {
+		name    string
+		runID   string
+		want    []app.WorkflowAnnotation
+		wantErr bool
+	}{
+		{
+			name:  "GetWorkflowRunAnnotations returns the annotations of the run",
+			runID: "work_1",
+			want: []app.WorkflowAnnotation{
+				{Path: "main.go", StartLine: 3, EndLine: 4, Level: "failure", Title: "lint",
+					Message: "unused variable"},
+			},
+		},
+		{
+			name:    "GetWorkflowRunAnnotations fails when invalid run is used",
+			runID:   "INVALID_RUN_ID",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rga.GetWorkflowRunAnnotations(context.Background(), "gh_username", "gh_reponame", tt.runID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetWorkflowRunAnnotations() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetWorkflowRunAnnotations() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRadicleGitHubActions_GetArtifactTestSummary(t *testing.T) {
+	rga := &RadicleGitHubActions{
+		logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+		git:    &MockGitOps{},
+		github: &MockGitHubOps{},
+	}
+	tests := []struct {
+		name       string
+		artifactID string
+		want       *app.TestSummary
+		wantErr    bool
+	}{
+		{
+			name:       "GetArtifactTestSummary returns the summary of the artifact",
+			artifactID: "artifact_1",
+			want: &app.TestSummary{Total: 3, Passed: 2, Failed: 1, Flaky: 1, FailedTests: []app.FailedTest{
+				{Name: "TestFail", Message: "boom"},
+				{Name: "TestFlaky", Message: "timeout", Flaky: true},
+			}},
+		},
+		{
+			name:       "GetArtifactTestSummary fails when invalid artifact is used",
+			artifactID: "INVALID_ARTIFACT_ID",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rga.GetArtifactTestSummary(context.Background(), "gh_username", "gh_reponame", tt.artifactID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetArtifactTestSummary() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetArtifactTestSummary() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRadicleGitHubActions_GetArtifactCoverage(t *testing.T) {
+	rga := &RadicleGitHubActions{
+		logger: slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{})),
+		git:    &MockGitOps{},
+		github: &MockGitHubOps{},
+	}
+	tests := []struct {
+		name       string
+		artifactID string
+		want       *app.Coverage
+		wantErr    bool
+	}{
+		{
+			name:       "GetArtifactCoverage returns the coverage of the artifact",
+			artifactID: "artifact_1",
+			want:       &app.Coverage{Covered: 80, Total: 100},
+		},
+		{
+			name:       "GetArtifactCoverage fails when invalid artifact is used",
+			artifactID: "INVALID_ARTIFACT_ID",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rga.GetArtifactCoverage(context.Background(), "gh_username", "gh_reponame", tt.artifactID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetArtifactCoverage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetArtifactCoverage() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,13 +1,13 @@
 package readerwriterbroker
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"log/slog"
 	"radicle-github-actions-adapter/app/broker"
-	"strconv"
 	"strings"
 )
 
@@ -15,87 +15,137 @@ type ReaderWriterBroker struct {
 	brokerReader io.Reader
 	brokerWriter io.Writer
 	logger       *slog.Logger
+	// strict rejects request messages with fields unknown to the adapter.
+	strict bool
+	// protocolVersion is negotiated from the last parsed request message, 0 until a request is parsed.
+	protocolVersion uint
 }
 
-func NewReaderWriterBroker(reader io.Reader, writer io.Writer, logger *slog.Logger) *ReaderWriterBroker {
+// NewReaderWriterBroker returns a ReaderWriterBroker reading requests from reader and writing responses to writer.
+// With strict set, request messages with unknown fields are rejected.
+func NewReaderWriterBroker(reader io.Reader, writer io.Writer, strict bool, logger *slog.Logger) *ReaderWriterBroker {
 	return &ReaderWriterBroker{
 		brokerReader: reader,
 		brokerWriter: writer,
+		strict:       strict,
 		logger:       logger,
 	}
 }
 
+// pushRequestMessage and patchRequestMessage accept the version of the request next to the event's fields.
+type pushRequestMessage struct {
+	Version uint `json:"version"`
+	broker.RequestPushEventMessage
+}
+
+type patchRequestMessage struct {
+	Version uint `json:"version"`
+	broker.RequestPatchEventMessage
+}
+
 // ParseRequestMessage reads a Request Message from broker message through ReaderWriterBroker.Reader
 // and parses it in to an app.RequestMessage.
+// All errors about the content of the message are a *broker.RequestError.
 func (sb *ReaderWriterBroker) ParseRequestMessage(ctx context.Context) (*broker.RequestMessage, error) {
-	input, err := io.ReadAll(sb.brokerReader)
+	var input json.RawMessage
+	err := json.NewDecoder(sb.brokerReader).Decode(&input)
 	if err != nil {
 		sb.logger.Error("could not read request message", "error", err.Error())
-		return nil, err
+		return nil, decodeError(err)
 	}
-	input = []byte(strings.ReplaceAll(string(input), "\n", ""))
 	sb.logger.Debug("received message from broker", "message", string(input))
-	messageType, err := sb.parseRequestMessageType(input)
+	requestType := broker.RequestTypeMessage{}
+	err = json.Unmarshal(input, &requestType)
+	if err != nil {
+		sb.logger.Error("could not unmarshal request message", "error", err.Error())
+		return nil, decodeError(err)
+	}
+	err = validateRequestType(requestType)
 	if err != nil {
 		sb.logger.Error("could not parse request message", "error", err.Error())
 		return nil, err
 	}
-	requestMessage := broker.RequestMessage{}
-	switch messageType {
+	requestMessage := broker.RequestMessage{Version: requestType.Version}
+	switch requestType.EventType {
 	case broker.RequestMessageTypePush:
-		requestMessageTypePush := broker.RequestPushEventMessage{}
-		err := json.Unmarshal(input, &requestMessageTypePush)
+		pushRequest := pushRequestMessage{}
+		err = sb.decode(input, &pushRequest)
+		if err == nil {
+			err = validatePushEvent(&pushRequest.RequestPushEventMessage)
+		}
 		if err != nil {
-			sb.logger.Error("could not unmarshal request message to push event message")
-			return nil, errors.New("could not unmarshal request message to push event message")
+			sb.logger.Error("could not parse push event message", "error", err.Error())
+			return nil, err
 		}
-		requestMessage.PushEvent = &requestMessageTypePush
-		requestMessage.Repo = requestMessageTypePush.Repository.ID
-		requestMessage.Commit = requestMessageTypePush.After
-		return &requestMessage, nil
+		requestMessage.PushEvent = &pushRequest.RequestPushEventMessage
+		requestMessage.Repo = pushRequest.Repository.ID
+		requestMessage.Commit = pushRequest.After
 	case broker.RequestMessageTypePatch:
-		requestMessageTypePatch := broker.RequestPatchEventMessage{}
-		err := json.Unmarshal(input, &requestMessageTypePatch)
+		patchRequest := patchRequestMessage{}
+		err = sb.decode(input, &patchRequest)
+		if err == nil {
+			err = validatePatchEvent(&patchRequest.RequestPatchEventMessage)
+		}
+		if err != nil {
+			sb.logger.Error("could not parse patch event message", "error", err.Error())
+			return nil, err
+		}
+		revision, err := selectPatchRevision(&patchRequest.Patch)
 		if err != nil {
-			sb.logger.Error("could not unmarshal request message to patch event message")
-			return nil, errors.New("could not unmarshal request message to patch event message")
+			sb.logger.Error("could not select patch revision", "error", err.Error())
+			return nil, err
 		}
-		requestMessage.PatchEvent = &requestMessageTypePatch
-		requestMessage.Repo = requestMessageTypePatch.Repository.ID
-		requestMessage.Commit = requestMessageTypePatch.Patch.After
-		return &requestMessage, nil
+		requestMessage.PatchEvent = &patchRequest.RequestPatchEventMessage
+		requestMessage.Repo = patchRequest.Repository.ID
+		requestMessage.Commit = revision.Oid
+		requestMessage.RevisionID = revision.ID
 	}
-	sb.logger.Error("not supported event type", "event type", messageType)
-	return nil, errors.New("not supported event type: " + string(messageType))
+	sb.protocolVersion = requestType.Version
+	return &requestMessage, nil
 }
 
-func (sb *ReaderWriterBroker) parseRequestMessageType(input []byte) (broker.RequestMessageType, error) {
-	brokerMessage := broker.RequestTypeMessage{}
-	err := json.Unmarshal(input, &brokerMessage)
-	if err != nil {
-		sb.logger.Error("could not unmarshal request message", "error", err.Error())
-		return "", errors.New("could not unmarshal request")
+// decode unmarshals input into message, rejecting unknown fields in strict mode.
+func (sb *ReaderWriterBroker) decode(input json.RawMessage, message any) error {
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	if sb.strict {
+		decoder.DisallowUnknownFields()
 	}
-	if brokerMessage.Request != "trigger" {
-		sb.logger.Error("not supported message request", "request", brokerMessage.Request)
-		return "", errors.New("not supported message request: " + brokerMessage.Request)
+	err := decoder.Decode(message)
+	if err != nil {
+		return decodeError(err)
 	}
-	if val, ok := broker.SupportedProtocolVersions[brokerMessage.Version]; !ok || !val {
-		sb.logger.Error("not supported message protocol version", "version", brokerMessage.Version)
-		return "", errors.New("not supported message protocol version: " + strconv.Itoa(int(brokerMessage.Version)))
+	return nil
+}
+
+// decodeError converts an encoding/json error into a *broker.RequestError naming the wrong field where possible.
+func decodeError(err error) error {
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) {
+		return &broker.RequestError{Field: typeError.Field, Value: "expected " + typeError.Type.String(),
+			Err: broker.ErrInvalidField}
 	}
-	switch brokerMessage.EventType {
-	case broker.RequestMessageTypePush:
-		return broker.RequestMessageTypePush, nil
-	case broker.RequestMessageTypePatch:
-		return broker.RequestMessageTypePatch, nil
+	if field, found := strings.CutPrefix(err.Error(), "json: unknown field "); found {
+		return &broker.RequestError{Field: strings.Trim(field, `"`), Err: broker.ErrUnknownField}
 	}
-	sb.logger.Error("not supported event type", "event type", brokerMessage.EventType)
-	return "", errors.New("not supported event type: " + string(brokerMessage.EventType))
+	return &broker.RequestError{Value: err.Error(), Err: broker.ErrMalformedRequest}
 }
 
-// ServeResponse writes the responseMessage to the ReaderWriterBroker.Writer
+// ServeResponse writes the responseMessage to the ReaderWriterBroker.Writer in the format of the protocol version
+// of the request message. Until a request message is parsed, version 1 is used.
 func (sb *ReaderWriterBroker) ServeResponse(ctx context.Context, responseMessage broker.ResponseMessage) error {
 	encoder := json.NewEncoder(sb.brokerWriter)
+	if sb.protocolVersion >= broker.ProtocolVersion2 {
+		return encoder.Encode(responseMessage.V2())
+	}
 	return encoder.Encode(responseMessage)
 }
+
+// ServeProgress writes the progressMessage to the ReaderWriterBroker.Writer. Progress messages are part of protocol
+// version 2 and later, for earlier versions broker.ErrProgressNotSupported is returned.
+func (sb *ReaderWriterBroker) ServeProgress(ctx context.Context, progressMessage broker.ProgressMessage) error {
+	if sb.protocolVersion < broker.ProtocolVersion2 {
+		return broker.ErrProgressNotSupported
+	}
+	encoder := json.NewEncoder(sb.brokerWriter)
+	return encoder.Encode(progressMessage)
+}
@@ -3,6 +3,7 @@ package readerwriterbroker
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
 	"log/slog"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewReaderWriterBroker(t *testing.T) {
@@ -38,7 +40,7 @@ func TestNewReaderWriterBroker(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			writer := &bytes.Buffer{}
-			got := NewReaderWriterBroker(tt.args.reader, writer, slog.New(slog.NewJSONHandler(os.Stderr,
+			got := NewReaderWriterBroker(tt.args.reader, writer, false, slog.New(slog.NewJSONHandler(os.Stderr,
 				&slog.HandlerOptions{})))
 			if gotWriter := writer.String(); gotWriter != tt.wantWriter {
 				t.Errorf("NewReaderWriterBroker() gotWriter = %v, want %v", gotWriter, tt.wantWriter)
@@ -68,13 +70,14 @@ func TestReaderWriterBroker_ParseRequestMessage(t *testing.T) {
 		{
 			name: "Test valid push request to ParseRequestMessage",
 			fields: fields{
-				BrokerReader: strings.NewReader(`{"version": 1,"request": "trigger","event_type": "push","pusher": {"id": "did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa","alias": "node_alias"},"before": "<BEFORE_COMMIT>","after": "<AFTER_COMMIT>","commits": ["<SOME_OTHER_COMMIT_BEING_PUSHED>", "<AFTER_COMMIT>" ], "repository": { "id": "<RID>", "name": "heartwood", "description": "Radicle is a sovereign peer-to-peer network for code collaboration, built on top of Git.","private": false,"default_branch": "main","delegates": ["did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa", "did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRb"]}}`),
+				BrokerReader: strings.NewReader(`{"version": 1,"request": "trigger","event_type": "push","pusher": {"id": "did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa","alias": "node_alias"},"before": "<BEFORE_COMMIT>","after": "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7","commits": ["<SOME_OTHER_COMMIT_BEING_PUSHED>", "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7" ], "repository": { "id": "rad:z3gqcJUoA1n9HaHKufZs5FCSGazv5", "name": "heartwood", "description": "Radicle is a sovereign peer-to-peer network for code collaboration, built on top of Git.","private": false,"default_branch": "main","delegates": ["did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa", "did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRb"]}}`),
 				BrokerWriter: &bytes.Buffer{},
 			},
 			args: args{ctx: context.TODO()},
 			want: &broker.RequestMessage{
-				Repo:   "<RID>",
-				Commit: "<AFTER_COMMIT>",
+				Version: 1,
+				Repo:    "rad:z3gqcJUoA1n9HaHKufZs5FCSGazv5",
+				Commit:  "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7",
 				PushEvent: &broker.RequestPushEventMessage{
 					Request:   "trigger",
 					EventType: "push",
@@ -83,10 +86,10 @@ func TestReaderWriterBroker_ParseRequestMessage(t *testing.T) {
 						Alias: "node_alias",
 					},
 					Before:  "<BEFORE_COMMIT>",
-					After:   "<AFTER_COMMIT>",
-					Commits: []string{"<SOME_OTHER_COMMIT_BEING_PUSHED>", "<AFTER_COMMIT>"},
+					After:   "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7",
+					Commits: []string{"<SOME_OTHER_COMMIT_BEING_PUSHED>", "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7"},
 					Repository: broker.Repository{
-						ID:            "<RID>",
+						ID:            "rad:z3gqcJUoA1n9HaHKufZs5FCSGazv5",
 						Name:          "heartwood",
 						Description:   "Radicle is a sovereign peer-to-peer network for code collaboration, built on top of Git.",
 						Private:       false,
@@ -111,7 +114,7 @@ func TestReaderWriterBroker_ParseRequestMessage(t *testing.T) {
 		{
 			name: "Test invalid push request to ParseRequestMessage - invalid request",
 			fields: fields{
-				BrokerReader: strings.NewReader(`{"version": 1,"request": "trigger","event_type": "push","pusher": {"id": 123,"alias": "node_alias"},"before": "<BEFORE_COMMIT>","after": "<AFTER_COMMIT>","commits": ["<SOME_OTHER_COMMIT_BEING_PUSHED>", "<AFTER_COMMIT>" ], "repository": { "id": "<RID>", "name": "heartwood", "description": "Radicle is a sovereign peer-to-peer network for code collaboration, built on top of Git.","private": false,"default_branch": "main","delegates": ["did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa", "did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRb"]}}`),
+				BrokerReader: strings.NewReader(`{"version": 1,"request": "trigger","event_type": "push","pusher": {"id": 123,"alias": "node_alias"},"before": "<BEFORE_COMMIT>","after": "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7","commits": ["<SOME_OTHER_COMMIT_BEING_PUSHED>", "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7" ], "repository": { "id": "rad:z3gqcJUoA1n9HaHKufZs5FCSGazv5", "name": "heartwood", "description": "Radicle is a sovereign peer-to-peer network for code collaboration, built on top of Git.","private": false,"default_branch": "main","delegates": ["did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa", "did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRb"]}}`),
 				BrokerWriter: &bytes.Buffer{},
 			},
 			args:    args{ctx: context.TODO()},
@@ -121,7 +124,7 @@ func TestReaderWriterBroker_ParseRequestMessage(t *testing.T) {
 		{
 			name: "Test invalid request to ParseRequestMessage - invalid event_type",
 			fields: fields{
-				BrokerReader: strings.NewReader(`{"version": 1,"request": "trigger","event_type": "unknown","pusher": {"id": "did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa","alias": "node_alias"},"before": "<BEFORE_COMMIT>","after": "<AFTER_COMMIT>","commits": ["<SOME_OTHER_COMMIT_BEING_PUSHED>", "<AFTER_COMMIT>" ], "repository": { "id": "<RID>", "name": "heartwood", "description": "Radicle is a sovereign peer-to-peer network for code collaboration, built on top of Git.","private": false,"default_branch": "main","delegates": ["did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa", "did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRb"]}}`),
+				BrokerReader: strings.NewReader(`{"version": 1,"request": "trigger","event_type": "unknown","pusher": {"id": "did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa","alias": "node_alias"},"before": "<BEFORE_COMMIT>","after": "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7","commits": ["<SOME_OTHER_COMMIT_BEING_PUSHED>", "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7" ], "repository": { "id": "rad:z3gqcJUoA1n9HaHKufZs5FCSGazv5", "name": "heartwood", "description": "Radicle is a sovereign peer-to-peer network for code collaboration, built on top of Git.","private": false,"default_branch": "main","delegates": ["did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa", "did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRb"]}}`),
 				BrokerWriter: &bytes.Buffer{},
 			},
 			args:    args{ctx: context.TODO()},
@@ -132,7 +135,7 @@ func TestReaderWriterBroker_ParseRequestMessage(t *testing.T) {
 		{
 			name: "Test invalid request to ParseRequestMessage - invalid request",
 			fields: fields{
-				BrokerReader: strings.NewReader(`{"version": 1,"request": "some request","event_type": "push","pusher": {"id": "did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa","alias": "node_alias"},"before": "<BEFORE_COMMIT>","after": "<AFTER_COMMIT>","commits": ["<SOME_OTHER_COMMIT_BEING_PUSHED>", "<AFTER_COMMIT>" ], "repository": { "id": "<RID>", "name": "heartwood", "description": "Radicle is a sovereign peer-to-peer network for code collaboration, built on top of Git.","private": false,"default_branch": "main","delegates": ["did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa", "did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRb"]}}`),
+				BrokerReader: strings.NewReader(`{"version": 1,"request": "some request","event_type": "push","pusher": {"id": "did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa","alias": "node_alias"},"before": "<BEFORE_COMMIT>","after": "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7","commits": ["<SOME_OTHER_COMMIT_BEING_PUSHED>", "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7" ], "repository": { "id": "rad:z3gqcJUoA1n9HaHKufZs5FCSGazv5", "name": "heartwood", "description": "Radicle is a sovereign peer-to-peer network for code collaboration, built on top of Git.","private": false,"default_branch": "main","delegates": ["did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa", "did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRb"]}}`),
 				BrokerWriter: &bytes.Buffer{},
 			},
 			args:    args{ctx: context.TODO()},
@@ -143,13 +146,15 @@ func TestReaderWriterBroker_ParseRequestMessage(t *testing.T) {
 		{
 			name: "Test valid patch request to ParseRequestMessage",
 			fields: fields{
-				BrokerReader: strings.NewReader(`{"version": 1,"request":"trigger","event_type":"patch","action":"created","patch":{"id":"<PATCH_ID>","author":{"id":"did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa","alias":"node_alias"},"title":"Add description in README","state":{"status":"Open","conflicts":[{"revision_id":"rev1","oid":"id1"}]},"before":"<BEFORE_COMMIT>","after":"<AFTER_COMMIT>","commits":["<SOME_OTHER_COMMIT_BEING_PUSHED>","<AFTER_COMMIT>"],"target":"delegates","labels":["small","goodFirstIssue","enhancement","bug"],"assignees":["did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa"],"revisions":[{"id":"41aafe22200464bf905b143d4233f7f1fa4a9123","author":{"id":"did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa","alias":"my_alias"},"description":"The revision description","base":"193ed2f675ac6b0d1ab79ed65057c8a56a4fab23","oid":"f0f5d38ffa8d54a7cc737fc4e75ab1e2e178eaa1","timestamp":1699437445}]},"repository":{"id":"<RID>","name":"heartwood","description":"Radicle is a sovereign peer-to-peer network for code collaboration, built on top of Git.","private":false,"default_branch":"main","delegates":["did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa","did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRb"]}}`),
+				BrokerReader: strings.NewReader(`{"version": 1,"request":"trigger","event_type":"patch","action":"created","patch":{"id":"<PATCH_ID>","author":{"id":"did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa","alias":"node_alias"},"title":"Add description in README","state":{"status":"Open","conflicts":[{"revision_id":"rev1","oid":"id1"}]},"before":"<BEFORE_COMMIT>","after":"a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7","commits":["<SOME_OTHER_COMMIT_BEING_PUSHED>","a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7"],"target":"delegates","labels":["small","goodFirstIssue","enhancement","bug"],"assignees":["did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa"],"revisions":[{"id":"41aafe22200464bf905b143d4233f7f1fa4a9123","author":{"id":"did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa","alias":"my_alias"},"description":"The revision description","base":"193ed2f675ac6b0d1ab79ed65057c8a56a4fab23","oid":"a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7","timestamp":1699437445}]},"repository":{"id":"rad:z3gqcJUoA1n9HaHKufZs5FCSGazv5","name":"heartwood","description":"Radicle is a sovereign peer-to-peer network for code collaboration, built on top of Git.","private":false,"default_branch":"main","delegates":["did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa","did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRb"]}}`),
 				BrokerWriter: &bytes.Buffer{},
 			},
 			args: args{ctx: context.TODO()},
 			want: &broker.RequestMessage{
-				Repo:   "<RID>",
-				Commit: "<AFTER_COMMIT>",
+				Version:    1,
+				Repo:       "rad:z3gqcJUoA1n9HaHKufZs5FCSGazv5",
+				Commit:     "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7",
+				RevisionID: "41aafe22200464bf905b143d4233f7f1fa4a9123",
 				PatchEvent: &broker.RequestPatchEventMessage{
 					Request:   "trigger",
 					EventType: "patch",
@@ -171,8 +176,8 @@ func TestReaderWriterBroker_ParseRequestMessage(t *testing.T) {
 							},
 						},
 						Before:    "<BEFORE_COMMIT>",
-						After:     "<AFTER_COMMIT>",
-						Commits:   []string{"<SOME_OTHER_COMMIT_BEING_PUSHED>", "<AFTER_COMMIT>"},
+						After:     "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7",
+						Commits:   []string{"<SOME_OTHER_COMMIT_BEING_PUSHED>", "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7"},
 						Target:    "delegates",
 						Labels:    []string{"small", "goodFirstIssue", "enhancement", "bug"},
 						Assignees: []string{"did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa"},
@@ -185,13 +190,13 @@ func TestReaderWriterBroker_ParseRequestMessage(t *testing.T) {
 								},
 								Description: "The revision description",
 								Base:        "193ed2f675ac6b0d1ab79ed65057c8a56a4fab23",
-								Oid:         "f0f5d38ffa8d54a7cc737fc4e75ab1e2e178eaa1",
+								Oid:         "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7",
 								Timestamp:   1699437445,
 							},
 						},
 					},
 					Repository: broker.Repository{
-						ID:            "<RID>",
+						ID:            "rad:z3gqcJUoA1n9HaHKufZs5FCSGazv5",
 						Name:          "heartwood",
 						Description:   "Radicle is a sovereign peer-to-peer network for code collaboration, built on top of Git.",
 						Private:       false,
@@ -205,7 +210,7 @@ func TestReaderWriterBroker_ParseRequestMessage(t *testing.T) {
 		{
 			name: "Test invalid patch request to ParseRequestMessage - invalid request",
 			fields: fields{
-				BrokerReader: strings.NewReader(`{"version": 1,"request":"trigger","event_type":"patch","action":"created","patch":{"id":123,"author":{"id":"did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa","alias":"node_alias"},"title":"Add description in README","state":{"status":"Open","conflicts":[{"revision_id":"rev1","oid":"id1"}]},"before":"<BEFORE_COMMIT>","after":"<AFTER_COMMIT>","commits":["<SOME_OTHER_COMMIT_BEING_PUSHED>","<AFTER_COMMIT>"],"target":"delegates","labels":["small","goodFirstIssue","enhancement","bug"],"assignees":["did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa"],"revisions":[{"id":"41aafe22200464bf905b143d4233f7f1fa4a9123","author":{"id":"did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa","alias":"my_alias"},"description":"The revision description","base":"193ed2f675ac6b0d1ab79ed65057c8a56a4fab23","oid":"f0f5d38ffa8d54a7cc737fc4e75ab1e2e178eaa1","timestamp":1699437445}]},"repository":{"id":"<RID>","name":"heartwood","description":"Radicle is a sovereign peer-to-peer network for code collaboration, built on top of Git.","private":false,"default_branch":"main","delegates":["did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa","did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRb"]}}`),
+				BrokerReader: strings.NewReader(`{"version": 1,"request":"trigger","event_type":"patch","action":"created","patch":{"id":123,"author":{"id":"did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa","alias":"node_alias"},"title":"Add description in README","state":{"status":"Open","conflicts":[{"revision_id":"rev1","oid":"id1"}]},"before":"<BEFORE_COMMIT>","after":"a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7","commits":["<SOME_OTHER_COMMIT_BEING_PUSHED>","a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7"],"target":"delegates","labels":["small","goodFirstIssue","enhancement","bug"],"assignees":["did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa"],"revisions":[{"id":"41aafe22200464bf905b143d4233f7f1fa4a9123","author":{"id":"did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa","alias":"my_alias"},"description":"The revision description","base":"193ed2f675ac6b0d1ab79ed65057c8a56a4fab23","oid":"f0f5d38ffa8d54a7cc737fc4e75ab1e2e178eaa1","timestamp":1699437445}]},"repository":{"id":"rad:z3gqcJUoA1n9HaHKufZs5FCSGazv5","name":"heartwood","description":"Radicle is a sovereign peer-to-peer network for code collaboration, built on top of Git.","private":false,"default_branch":"main","delegates":["did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRa","did:key:z6MkltRpzcq2ybm13yQpyre58JUeMvZY6toxoZVpLZ8YabRb"]}}`),
 				BrokerWriter: &bytes.Buffer{},
 			},
 			args:    args{ctx: context.TODO()},
@@ -377,3 +382,282 @@ func TestReaderWriterBroker_ServeErrorResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestReaderWriterBroker_ServeResponseProtocolVersions(t *testing.T) {
+	responseMessage := broker.ResponseMessage{
+		Response: "finished",
+		RunID:    &broker.RunID{ID: "550e8400-e29b-41d4-a716-446655440000"},
+		Result:   "success",
+		InfoURL:  "https://github.com/user/repo/commit/commit_hash",
+		Skipped:  true,
+		ResultDetails: []broker.WorkflowDetails{
+			{
+				WorkflowID:        "1",
+				WorkflowName:      "build",
+				WorkflowResult:    "success",
+				WorkflowAttempt:   1,
+				WorkflowRunNumber: 3,
+				WorkflowURL:       "https://github.com/user/repo/actions/runs/1",
+				WorkflowArtifacts: []broker.WorkflowArtifact{
+					{Id: "2", Name: "binary", Url: "artifact-url", ApiUrl: "artifact-api-url"},
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name    string
+		request string
+		want    string
+	}{
+		{
+			name:    "ServeResponse defaults to version 1 before parsing a request",
+			request: "",
+			want: `{"response":"finished","run_id":{"id":"550e8400-e29b-41d4-a716-446655440000"},` +
+				`"result":"success"}` + "\n",
+		},
+		{
+			name: "ServeResponse uses version 1 for a version 1 request",
+			request: `{"version": 1,"request": "trigger","event_type": "push",` +
+				`"after": "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7",` +
+				`"repository": {"id": "rad:z3gqcJUoA1n9HaHKufZs5FCSGazv5"}}`,
+			want: `{"response":"finished","run_id":{"id":"550e8400-e29b-41d4-a716-446655440000"},` +
+				`"result":"success"}` + "\n",
+		},
+		{
+			name: "ServeResponse includes the result details for a version 2 request",
+			request: `{"version": 2,"request": "trigger","event_type": "push",` +
+				`"after": "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7",` +
+				`"repository": {"id": "rad:z3gqcJUoA1n9HaHKufZs5FCSGazv5"}}`,
+			want: `{"response":"finished","run_id":{"id":"550e8400-e29b-41d4-a716-446655440000"},` +
+				`"result":"success","info_url":"https://github.com/user/repo/commit/commit_hash","skipped":true,` +
+				`"result_details":[{"workflow_id":"1","workflow_name":"build","workflow_result":"success",` +
+				`"workflow_attempt":1,"workflow_run_number":3,` +
+				`"workflow_url":"https://github.com/user/repo/actions/runs/1","workflow_artifacts":[{"id":"2",` +
+				`"name":"binary","url":"artifact-url","api_url":"artifact-api-url"}]}]}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := &bytes.Buffer{}
+			sb := NewReaderWriterBroker(strings.NewReader(tt.request), writer, false,
+				slog.New(slog.NewJSONHandler(os.Stderr, nil)))
+			if len(tt.request) > 0 {
+				if _, err := sb.ParseRequestMessage(context.TODO()); err != nil {
+					t.Fatalf("ParseRequestMessage() error = %v", err)
+				}
+			}
+			if err := sb.ServeResponse(context.TODO(), responseMessage); err != nil {
+				t.Fatalf("ServeResponse() error = %v", err)
+			}
+			if got := writer.String(); got != tt.want {
+				t.Errorf("ServeResponse() got = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReaderWriterBroker_ServeProgressProtocolVersions(t *testing.T) {
+	progressMessage := broker.ProgressMessage{
+		Response:  "progress",
+		RunID:     &broker.RunID{ID: "550e8400-e29b-41d4-a716-446655440000"},
+		Timestamp: time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC),
+		Workflows: []broker.WorkflowProgress{
+			{WorkflowID: "1", WorkflowName: "build", Status: "success", PreviousStatus: "in_progress",
+				WorkflowURL: "https://github.com/user/repo/actions/runs/1"},
+		},
+	}
+	tests := []struct {
+		name    string
+		request string
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "ServeProgress is not supported before parsing a request",
+			request: "",
+			wantErr: broker.ErrProgressNotSupported,
+		},
+		{
+			name: "ServeProgress is not supported for a version 1 request",
+			request: `{"version": 1,"request": "trigger","event_type": "push",` +
+				`"after": "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7",` +
+				`"repository": {"id": "rad:z3gqcJUoA1n9HaHKufZs5FCSGazv5"}}`,
+			wantErr: broker.ErrProgressNotSupported,
+		},
+		{
+			name: "ServeProgress writes the progress message for a version 2 request",
+			request: `{"version": 2,"request": "trigger","event_type": "push",` +
+				`"after": "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7",` +
+				`"repository": {"id": "rad:z3gqcJUoA1n9HaHKufZs5FCSGazv5"}}`,
+			want: `{"response":"progress","run_id":{"id":"550e8400-e29b-41d4-a716-446655440000"},` +
+				`"timestamp":"2024-01-01T00:01:00Z","workflows":[{"workflow_id":"1","workflow_name":"build",` +
+				`"status":"success","previous_status":"in_progress",` +
+				`"workflow_url":"https://github.com/user/repo/actions/runs/1"}]}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := &bytes.Buffer{}
+			sb := NewReaderWriterBroker(strings.NewReader(tt.request), writer, false,
+				slog.New(slog.NewJSONHandler(os.Stderr, nil)))
+			if len(tt.request) > 0 {
+				if _, err := sb.ParseRequestMessage(context.TODO()); err != nil {
+					t.Fatalf("ParseRequestMessage() error = %v", err)
+				}
+			}
+			if err := sb.ServeProgress(context.TODO(), progressMessage); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ServeProgress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := writer.String(); got != tt.want {
+				t.Errorf("ServeProgress() got = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReaderWriterBroker_ParseRequestMessageValidation(t *testing.T) {
+	const repository = `"repository": {"id": "rad:z3gqcJUoA1n9HaHKufZs5FCSGazv5", ` +
+		`"description": "first line\nsecond line"}`
+	const commit = "a6f8e4f6a1c7f2b2d0c3e5b8f9a1d2c3e4f5a6b7"
+	tests := []struct {
+		name      string
+		request   string
+		strict    bool
+		wantErr   error
+		wantField string
+	}{
+		{
+			name:    "valid push request keeps newlines in strings",
+			request: `{"version": 1, "request": "trigger", "event_type": "push", "after": "` + commit + `", ` + repository + `}`,
+		},
+		{
+			name: "valid patch request in strict mode",
+			request: `{"version": 2, "request": "trigger", "event_type": "patch", "action": "created", ` +
+				`"patch": {"id": "patch_id", "after": "` + commit + `", ` +
+				`"revisions": [{"id": "revision_id", "oid": "` + commit + `"}]}, ` +
+				repository + `}`,
+			strict: true,
+		},
+		{
+			name:    "malformed request",
+			request: `{"version": 1, "request": "trigger"`,
+			wantErr: broker.ErrMalformedRequest,
+		},
+		{
+			name:      "missing request",
+			request:   `{"version": 1, "event_type": "push"}`,
+			wantErr:   broker.ErrMissingField,
+			wantField: "request",
+		},
+		{
+			name:      "unsupported version",
+			request:   `{"version": 9, "request": "trigger", "event_type": "push"}`,
+			wantErr:   broker.ErrUnsupportedField,
+			wantField: "version",
+		},
+		{
+			name:      "invalid field type",
+			request:   `{"version": 1, "request": "trigger", "event_type": "push", "after": 123}`,
+			wantErr:   broker.ErrInvalidField,
+			wantField: "after",
+		},
+		{
+			name: "invalid repo ID",
+			request: `{"version": 1, "request": "trigger", "event_type": "push", "after": "` + commit + `", ` +
+				`"repository": {"id": "z3gqcJUoA1n9HaHKufZs5FCSGazv5"}}`,
+			wantErr:   broker.ErrInvalidField,
+			wantField: "repository.id",
+		},
+		{
+			name:      "invalid commit",
+			request:   `{"version": 1, "request": "trigger", "event_type": "push", "after": "HEAD", ` + repository + `}`,
+			wantErr:   broker.ErrInvalidField,
+			wantField: "after",
+		},
+		{
+			name: "patch without revisions",
+			request: `{"version": 1, "request": "trigger", "event_type": "patch", ` +
+				`"patch": {"id": "patch_id", "after": "` + commit + `", "revisions": []}, ` + repository + `}`,
+			wantErr:   broker.ErrMissingField,
+			wantField: "patch.revisions",
+		},
+		{
+			name: "unknown field is accepted in lenient mode",
+			request: `{"version": 1, "request": "trigger", "event_type": "push", "after": "` + commit + `", ` +
+				`"unknown": true, ` + repository + `}`,
+		},
+		{
+			name: "unknown field is rejected in strict mode",
+			request: `{"version": 1, "request": "trigger", "event_type": "push", "after": "` + commit + `", ` +
+				`"unknown": true, ` + repository + `}`,
+			strict:    true,
+			wantErr:   broker.ErrUnknownField,
+			wantField: "unknown",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sb := NewReaderWriterBroker(strings.NewReader(tt.request), &bytes.Buffer{}, tt.strict,
+				slog.New(slog.NewJSONHandler(os.Stderr, nil)))
+			got, err := sb.ParseRequestMessage(context.TODO())
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("ParseRequestMessage() error = %v", err)
+				}
+				if got.Commit != commit {
+					t.Errorf("ParseRequestMessage() commit = %s, want %s", got.Commit, commit)
+				}
+				if got.PushEvent != nil && got.PushEvent.Repository.Description != "first line\nsecond line" {
+					t.Errorf("ParseRequestMessage() description = %q", got.PushEvent.Repository.Description)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseRequestMessage() error = %v, want %v", err, tt.wantErr)
+			}
+			var requestError *broker.RequestError
+			if !errors.As(err, &requestError) || requestError.Field != tt.wantField {
+				t.Errorf("ParseRequestMessage() error = %v, want field %q", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestSelectPatchRevision(t *testing.T) {
+	revisions := []broker.PatchRevision{
+		{ID: "second", Oid: "2222222222222222222222222222222222222222", Timestamp: 200},
+		{ID: "first", Oid: "1111111111111111111111111111111111111111", Timestamp: 100},
+	}
+	tests := []struct {
+		name    string
+		after   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "selects the revision matching the after commit whatever the order",
+			after: "1111111111111111111111111111111111111111",
+			want:  "first",
+		},
+		{
+			name: "selects the latest revision without an after commit",
+			want: "second",
+		},
+		{
+			name:    "fails when no revision matches the after commit",
+			after:   "3333333333333333333333333333333333333333",
+			wantErr: broker.ErrRevisionNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectPatchRevision(&broker.PatchDetails{After: tt.after, Revisions: revisions})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("selectPatchRevision() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got.ID != tt.want {
+				t.Errorf("selectPatchRevision() got = %s, want %s", got.ID, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package readerwriterbroker
+
+import (
+	"radicle-github-actions-adapter/app/broker"
+	"regexp"
+	"strconv"
+)
+
+var (
+	// repoIDPattern matches a Radicle repository ID, a multibase base58btc encoded identifier, e.g.
+	// rad:z3gqcJUoA1n9HaHKufZs5FCSGazv5.
+	repoIDPattern = regexp.MustCompile(`^rad:z[1-9A-HJ-NP-Za-km-z]+$`)
+	commitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+)
+
+// validateRequestType checks that the message is a trigger request of a supported protocol version and event type.
+func validateRequestType(requestType broker.RequestTypeMessage) error {
+	if len(requestType.Request) == 0 {
+		return &broker.RequestError{Field: "request", Err: broker.ErrMissingField}
+	}
+	if requestType.Request != "trigger" {
+		return &broker.RequestError{Field: "request", Value: requestType.Request, Err: broker.ErrUnsupportedField}
+	}
+	if val, ok := broker.SupportedProtocolVersions[requestType.Version]; !ok || !val {
+		return &broker.RequestError{Field: "version", Value: strconv.Itoa(int(requestType.Version)),
+			Err: broker.ErrUnsupportedField}
+	}
+	switch requestType.EventType {
+	case broker.RequestMessageTypePush, broker.RequestMessageTypePatch:
+		return nil
+	case "":
+		return &broker.RequestError{Field: "event_type", Err: broker.ErrMissingField}
+	}
+	return &broker.RequestError{Field: "event_type", Value: string(requestType.EventType),
+		Err: broker.ErrUnsupportedField}
+}
+
+func validatePushEvent(pushEvent *broker.RequestPushEventMessage) error {
+	err := validateRepository(pushEvent.Repository)
+	if err != nil {
+		return err
+	}
+	return validateCommit("after", pushEvent.After)
+}
+
+func validatePatchEvent(patchEvent *broker.RequestPatchEventMessage) error {
+	err := validateRepository(patchEvent.Repository)
+	if err != nil {
+		return err
+	}
+	if len(patchEvent.Patch.ID) == 0 {
+		return &broker.RequestError{Field: "patch.id", Err: broker.ErrMissingField}
+	}
+	if len(patchEvent.Patch.After) > 0 {
+		err = validateCommit("patch.after", patchEvent.Patch.After)
+		if err != nil {
+			return err
+		}
+	}
+	if len(patchEvent.Patch.Revisions) == 0 {
+		return &broker.RequestError{Field: "patch.revisions", Err: broker.ErrMissingField}
+	}
+	for i, revision := range patchEvent.Patch.Revisions {
+		field := "patch.revisions." + strconv.Itoa(i)
+		if len(revision.ID) == 0 {
+			return &broker.RequestError{Field: field + ".id", Err: broker.ErrMissingField}
+		}
+		err = validateCommit(field+".oid", revision.Oid)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectPatchRevision returns the revision under test. It is the one whose Oid is the patch's After commit, or the
+// most recent revision when After is not set, whatever the order of the revisions in the message.
+func selectPatchRevision(patch *broker.PatchDetails) (*broker.PatchRevision, error) {
+	var selected *broker.PatchRevision
+	for i, revision := range patch.Revisions {
+		if len(patch.After) > 0 {
+			if revision.Oid == patch.After {
+				return &patch.Revisions[i], nil
+			}
+			continue
+		}
+		if selected == nil || revision.Timestamp > selected.Timestamp {
+			selected = &patch.Revisions[i]
+		}
+	}
+	if selected == nil {
+		return nil, &broker.RequestError{Field: "patch.after", Value: patch.After, Err: broker.ErrRevisionNotFound}
+	}
+	return selected, nil
+}
+
+func validateRepository(repository broker.Repository) error {
+	if len(repository.ID) == 0 {
+		return &broker.RequestError{Field: "repository.id", Err: broker.ErrMissingField}
+	}
+	if !repoIDPattern.MatchString(repository.ID) {
+		return &broker.RequestError{Field: "repository.id", Value: repository.ID, Err: broker.ErrInvalidField}
+	}
+	return nil
+}
+
+func validateCommit(field, commit string) error {
+	if len(commit) == 0 {
+		return &broker.RequestError{Field: field, Err: broker.ErrMissingField}
+	}
+	if !commitPattern.MatchString(commit) {
+		return &broker.RequestError{Field: field, Value: commit, Err: broker.ErrInvalidField}
+	}
+	return nil
+}